@@ -0,0 +1,294 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/challenge-league/nakama-go/context"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	AUDIT_COLLECTION = "audit_data"
+
+	MATCH_ADMIN_ACTION_FORCE_START     = "force-start"
+	MATCH_ADMIN_ACTION_FORCE_END       = "force-end"
+	MATCH_ADMIN_ACTION_SET_WINNER      = "set-winner"
+	MATCH_ADMIN_ACTION_VOID            = "void"
+	MATCH_ADMIN_ACTION_REASSIGN_TEAM   = "reassign-team"
+	MATCH_ADMIN_ACTION_REMOVE_PLAYER   = "remove-player"
+	MATCH_ADMIN_ACTION_EXTEND_DURATION = "extend-duration"
+)
+
+// AuditEvent is a single moderator action stored in AUDIT_COLLECTION
+type AuditEvent struct {
+	ID                string
+	Action            string
+	MatchID           string
+	StorageCollection string
+	UserID            string
+	DiscordID         string
+	TargetUserID      string
+	TeamNumber        int
+	Hours             int
+	Draw              bool
+	Reason            string
+	DateTime          time.Time
+}
+
+type MatchAdminRequest struct {
+	AuditEvent *AuditEvent
+}
+
+func PrintAuditEvents(auditEvents []*AuditEvent) string {
+	return ExecuteTemplate(
+		`{{range $index, $element := .}}> {{.DateTime | formatTimeAsDate}} <@{{.DiscordID}}> **{{.Action}}** {{.MatchID}}: {{.Reason}}
+{{end}}`,
+		auditEvents)
+}
+
+func getAuditEventList(cmdBuilder *commandsBuilder, matchID string) ([]*AuditEvent, error) {
+	objects, err := listAllUserStorageObjects(cmdBuilder, AUDIT_COLLECTION, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var auditEvents []*AuditEvent
+	for _, object := range objects {
+		var auditEvent *AuditEvent
+		if err := json.Unmarshal([]byte(object.Value), &auditEvent); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		if matchID == "" || auditEvent.MatchID == matchID {
+			auditEvents = append(auditEvents, auditEvent)
+		}
+	}
+	sort.SliceStable(auditEvents, func(i, j int) bool {
+		return auditEvents[i].DateTime.After(auditEvents[j].DateTime)
+	})
+	return auditEvents, nil
+}
+
+func matchAdminOverride(cmdBuilder *commandsBuilder, cmd *cobra.Command, auditEvent *AuditEvent) error {
+	reason, _ := cmd.Flags().GetString("reason")
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return fmt.Errorf("reason is **required** for the moderator actions")
+	}
+	archive, _ := cmd.Flags().GetBool("archive")
+	collection := MATCH_COLLECTION
+	if archive {
+		collection = MATCH_ARCHIVE_COLLECTION
+	}
+
+	account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	matchState, err := getMatchState(cmdBuilder, auditEvent.MatchID, collection)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if matchState == nil {
+		return fmt.Errorf("No match found for **%v** in %v", auditEvent.MatchID, collection)
+	}
+
+	if auditEvent.TargetUserID != "" && !IsUserIDInMatch(auditEvent.TargetUserID, matchState) {
+		return fmt.Errorf("User is not a player of the match **%v**", matchState.MatchID)
+	}
+	if auditEvent.Action == MATCH_ADMIN_ACTION_SET_WINNER || auditEvent.Action == MATCH_ADMIN_ACTION_REASSIGN_TEAM {
+		if !auditEvent.Draw && (auditEvent.TeamNumber < 0 || auditEvent.TeamNumber > (len(matchState.Teams)-1)) {
+			return fmt.Errorf("Incorrect team number %v", auditEvent.TeamNumber)
+		}
+	}
+	if auditEvent.Action == MATCH_ADMIN_ACTION_EXTEND_DURATION {
+		if auditEvent.Hours < 1 {
+			return fmt.Errorf("extension must be at least 1 hour")
+		}
+		if matchState.Duration+time.Duration(auditEvent.Hours)*time.Hour > MAX_MATCH_DURATION_HOURS*time.Hour {
+			return fmt.Errorf("duration can not be more than %v hours", MAX_MATCH_DURATION_HOURS)
+		}
+	}
+
+	auditEvent.ID = context.GenerateString()
+	auditEvent.StorageCollection = collection
+	auditEvent.UserID = account.User.Id
+	auditEvent.DiscordID = account.CustomId
+	auditEvent.Reason = reason
+	auditEvent.DateTime = time.Now().UTC()
+
+	payload, _ := json.Marshal(&MatchAdminRequest{
+		AuditEvent: auditEvent,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchAdminOverride", Payload: string(payload)})
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if result.Payload != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), result.Payload)
+	}
+	return nil
+}
+
+func setupMatchAdminFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("reason", "r", "", "Reason of the moderator action, **required**")
+	cmd.Flags().BoolP("archive", "a", false, "Apply to an archived match")
+}
+
+func getCmdAdminMatchAction(cmdBuilder *commandsBuilder, action string, use string, short string, args int, f func(*AuditEvent, []string) error) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   action + " [matchID]" + use,
+		Short: short,
+		Long:  short,
+		Args:  matchAll(cobra.ExactArgs(args)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			auditEvent := &AuditEvent{
+				Action:     action,
+				MatchID:    args[0],
+				TeamNumber: MATCH_RESULT_TEAM_UNKNOWN,
+			}
+			if f != nil {
+				if err := f(auditEvent, args[1:]); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+			return matchAdminOverride(cmdBuilder, cmd, auditEvent)
+		},
+	}
+	setupMatchAdminFlags(cmd)
+	return cmd
+}
+
+func getCmdAdminMatch(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmdAdminMatch := &cobra.Command{
+		Use:     "match",
+		Aliases: cmdMatchAliases,
+		Short:   "Moderator overrides of the match state",
+		Long:    `Moderator overrides of the match state, every action requires a --reason and is written to the audit trail`,
+	}
+
+	parseTeamNumber := func(auditEvent *AuditEvent, team string) error {
+		teamNumber, err := strconv.Atoi(team)
+		if err != nil {
+			return fmt.Errorf("Incorrect team number %v", team)
+		}
+		auditEvent.TeamNumber = teamNumber
+		return nil
+	}
+	parseUser := func(auditEvent *AuditEvent, user string) error {
+		account, err := getAccount(cmdBuilder, user)
+		if err != nil {
+			return err
+		}
+		auditEvent.TargetUserID = account.User.Id
+		return nil
+	}
+
+	cmdAdminMatch.AddCommand(getCmdAdminMatchAction(cmdBuilder, MATCH_ADMIN_ACTION_FORCE_START, "",
+		"Start the match even if not all users are ready", 1, nil))
+	cmdAdminMatch.AddCommand(getCmdAdminMatchAction(cmdBuilder, MATCH_ADMIN_ACTION_FORCE_END, "",
+		"End the match now and calculate the results", 1, nil))
+	cmdAdminMatch.AddCommand(getCmdAdminMatchAction(cmdBuilder, MATCH_ADMIN_ACTION_VOID, "",
+		"Void the match without any rewards", 1, nil))
+	cmdAdminMatch.AddCommand(getCmdAdminMatchAction(cmdBuilder, MATCH_ADMIN_ACTION_SET_WINNER, " [team]",
+		"Set the winner team of the match, use **draw** as the team for a draw", 2,
+		func(auditEvent *AuditEvent, args []string) error {
+			if args[0] == "draw" {
+				auditEvent.TeamNumber = MATCH_RESULT_TEAM_DRAW
+				auditEvent.Draw = true
+				return nil
+			}
+			return parseTeamNumber(auditEvent, args[0])
+		}))
+	cmdAdminMatch.AddCommand(getCmdAdminMatchAction(cmdBuilder, MATCH_ADMIN_ACTION_REASSIGN_TEAM, " [user] [team]",
+		"Move the player to another team", 3,
+		func(auditEvent *AuditEvent, args []string) error {
+			if err := parseUser(auditEvent, args[0]); err != nil {
+				return err
+			}
+			return parseTeamNumber(auditEvent, args[1])
+		}))
+	cmdAdminMatch.AddCommand(getCmdAdminMatchAction(cmdBuilder, MATCH_ADMIN_ACTION_REMOVE_PLAYER, " [user]",
+		"Remove the player from the match and close the player ticket", 2,
+		func(auditEvent *AuditEvent, args []string) error {
+			return parseUser(auditEvent, args[0])
+		}))
+	cmdAdminMatch.AddCommand(getCmdAdminMatchAction(cmdBuilder, MATCH_ADMIN_ACTION_EXTEND_DURATION, " [hours]",
+		"Extend the match duration by the number of hours", 2,
+		func(auditEvent *AuditEvent, args []string) error {
+			hours, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("Incorrect number of hours %v", args[0])
+			}
+			auditEvent.Hours = hours
+			return nil
+		}))
+	return cmdAdminMatch
+}
+
+func getCmdAdminAuditGet(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit [matchID]",
+		Short: "Get the moderator actions audit trail",
+		Long:  `Get the moderator actions audit trail`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			matchID := ""
+			if len(args) > 0 {
+				matchID = args[0]
+			}
+			auditEvents, err := getAuditEventList(cmdBuilder, matchID)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(auditEvents) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No moderator actions found")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), PrintAuditEvents(auditEvents))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func getCmdAdmin(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmdAdmin := &cobra.Command{
+		Use:   "admin",
+		Short: "**Moderator** commands",
+		Long:  `**Moderator** commands`,
+	}
+	return cmdAdmin
+}
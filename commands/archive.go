@@ -0,0 +1,333 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	DATE_LAYOUT = "2006-01-02"
+
+	MATCH_HISTORY_COLLECTION = "match_history_data"
+
+	MATCH_OUTCOME_WIN     = "win"
+	MATCH_OUTCOME_LOSS    = "loss"
+	MATCH_OUTCOME_DRAW    = "draw"
+	MATCH_OUTCOME_UNKNOWN = "unknown"
+
+	DEFAULT_HISTORY_LIMIT = 10
+)
+
+var MATCH_OUTCOMES = []string{MATCH_OUTCOME_WIN, MATCH_OUTCOME_LOSS, MATCH_OUTCOME_DRAW, MATCH_OUTCOME_UNKNOWN}
+
+// MatchSummary is a compact view of an archived match from the point of
+// view of a single user
+type MatchSummary struct {
+	MatchState *MatchState
+	Outcome    string
+	Reward     float64
+	Duration   time.Duration
+}
+
+type MatchHistoryFilter struct {
+	UserID          string
+	OpponentUserID  string
+	MatchProfile    string
+	Outcome         string
+	DateTimeFrom    time.Time
+	DateTimeTo      time.Time
+	MaxResultsCount int
+}
+
+func GetMatchOutcome(userID string, matchState *MatchState) string {
+	teamNumber := GetTeamNumberFromUserAndMatch(userID, matchState)
+	switch winner := GetMatchWinnerTeamNumber(matchState); {
+	case teamNumber == -1 || winner == MATCH_RESULT_TEAM_UNKNOWN:
+		return MATCH_OUTCOME_UNKNOWN
+	case winner == MATCH_RESULT_TEAM_DRAW:
+		return MATCH_OUTCOME_DRAW
+	case winner == teamNumber:
+		return MATCH_OUTCOME_WIN
+	default:
+		return MATCH_OUTCOME_LOSS
+	}
+}
+
+func GetMatchDateTimeEnd(matchState *MatchState) time.Time {
+	if !matchState.ActualDateTimeEnd.IsZero() {
+		return matchState.ActualDateTimeEnd
+	}
+	return matchState.DateTimeEnd
+}
+
+func GetMatchDuration(matchState *MatchState) time.Duration {
+	if matchState.ActualDuration != 0 {
+		return matchState.ActualDuration
+	}
+	return matchState.Duration
+}
+
+func NewMatchSummary(userID string, matchState *MatchState) *MatchSummary {
+	matchSummary := &MatchSummary{
+		MatchState: matchState,
+		Outcome:    GetMatchOutcome(userID, matchState),
+		Duration:   GetMatchDuration(matchState),
+	}
+	teamUser, _ := GetUserAndTeamNumberByUserID(userID, matchState)
+	if teamUser == nil {
+		teamUser = GetSubstitutedUserByUserID(userID, matchState)
+	}
+	if teamUser != nil {
+		matchSummary.Reward = teamUser.Reward
+	}
+	return matchSummary
+}
+
+func (f *MatchHistoryFilter) Match(matchState *MatchState) bool {
+	if !IsUserIDInMatch(f.UserID, matchState) {
+		return false
+	}
+	if f.OpponentUserID != "" {
+		opponentTeamNumber := GetTeamNumberFromUserAndMatch(f.OpponentUserID, matchState)
+		if opponentTeamNumber == -1 || opponentTeamNumber == GetTeamNumberFromUserAndMatch(f.UserID, matchState) {
+			return false
+		}
+	}
+	if f.MatchProfile != "" && f.MatchProfile != matchState.MatchProfile {
+		return false
+	}
+	if !f.DateTimeFrom.IsZero() && matchState.DateTimeStart.Before(f.DateTimeFrom) {
+		return false
+	}
+	if !f.DateTimeTo.IsZero() && !matchState.DateTimeStart.Before(f.DateTimeTo) {
+		return false
+	}
+	if f.Outcome != "" && f.Outcome != GetMatchOutcome(f.UserID, matchState) {
+		return false
+	}
+	return true
+}
+
+func PrintMatchSummary(matchSummary *MatchSummary) string {
+	var teams []string
+	for _, team := range matchSummary.MatchState.Teams {
+		var users []string
+		for _, teamUser := range team.TeamUsers {
+			users = append(users, fmt.Sprintf("<@%v>", teamUser.User.Nakama.CustomID))
+		}
+		teams = append(teams, strings.Join(users, " "))
+	}
+	return ExecuteTemplate(
+		`> `+"`{{.MatchState.MatchID}}`"+` {{.MatchState.DateTimeStart | formatTimeAsDate}} **{{.MatchState.MatchProfile}}** `+
+			strings.Join(teams, " vs ")+
+			` **{{.Outcome}}** {{.Duration | formatDuration}}{{ if .Reward | isFloatPositive }} **+{{.Reward}}**{{end}}{{ if .Reward | isFloatNegative }} **{{.Reward}}**{{end}}
+`,
+		matchSummary)
+}
+
+// GetMatchHistoryKey is the key of the archived match in the history of a
+// user, the storage lists the keys in order so the latest finished match
+// comes first
+func GetMatchHistoryKey(matchState *MatchState) string {
+	return fmt.Sprintf("%019d_%v", math.MaxInt64-GetMatchDateTimeEnd(matchState).Unix(), matchState.MatchID)
+}
+
+func unmarshalMatchStates(objects []*api.StorageObject) ([]*MatchState, error) {
+	var matchStateList []*MatchState
+	for _, object := range objects {
+		var matchState *MatchState
+		if err := json.Unmarshal([]byte(object.Value), &matchState); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		matchStateList = append(matchStateList, matchState)
+	}
+	return matchStateList, nil
+}
+
+// getArchivedMatchStateList returns the archived matches of the user that pass
+// the filter, the latest finished first. The server copies every archived
+// match to MATCH_HISTORY_COLLECTION of its players keyed by GetMatchHistoryKey,
+// only the history of the user is read and the cursor is the storage cursor,
+// so it stays valid when new matches are archived. At most MaxResultsCount
+// matches are returned with the cursor of the next one.
+func getArchivedMatchStateList(cmdBuilder *commandsBuilder, filter *MatchHistoryFilter, cursor string) ([]*MatchState, string, error) {
+	var matchStateList []*MatchState
+	for {
+		limit := MAX_LIST_LIMIT
+		if filter.MaxResultsCount > 0 && filter.MaxResultsCount-len(matchStateList) < limit {
+			limit = filter.MaxResultsCount - len(matchStateList)
+		}
+		storageObjectList, err := cmdBuilder.nakamaCtx.Client.ListStorageObjects(cmdBuilder.nakamaCtx.Ctx, &api.ListStorageObjectsRequest{
+			Collection: MATCH_HISTORY_COLLECTION,
+			UserId:     filter.UserID,
+			Limit:      &wrapperspb.Int32Value{Value: int32(limit)},
+			Cursor:     cursor,
+		})
+		if err != nil {
+			log.Error(err)
+			return nil, "", err
+		}
+
+		objects := storageObjectList.Objects
+		sort.Slice(objects, func(i, j int) bool {
+			return objects[i].Key < objects[j].Key
+		})
+		matchStates, err := unmarshalMatchStates(objects)
+		if err != nil {
+			log.Error(err)
+			return nil, "", err
+		}
+		for _, matchState := range matchStates {
+			if filter.Match(matchState) {
+				matchStateList = append(matchStateList, matchState)
+			}
+		}
+
+		if storageObjectList.Cursor == "" || storageObjectList.Cursor == cursor {
+			return matchStateList, "", nil
+		}
+		cursor = storageObjectList.Cursor
+		if filter.MaxResultsCount > 0 && len(matchStateList) >= filter.MaxResultsCount {
+			return matchStateList, cursor, nil
+		}
+	}
+}
+
+func parseDateFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(DATE_LAYOUT, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%v' is not a valid date, expected format: %v", value, DATE_LAYOUT)
+	}
+	return t, nil
+}
+
+func getCmdMatchHistory(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history [user]",
+		Aliases: []string{"hist", "h"},
+		Short:   "Browse the **finished matches** of a user",
+		Long: `Browse the **finished matches** of a user
+Use **--match** to see the details of an archived match`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			matchID, _ := cmd.Flags().GetString("match")
+			if matchID != "" {
+				matchState, err := getMatchState(cmdBuilder, matchID, MATCH_ARCHIVE_COLLECTION)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				if matchState == nil {
+					return fmt.Errorf("No archived match found for **%v**", matchID)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), PrintMatchState(matchState))
+				return nil
+			}
+
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(args) > 0 {
+				if account, err = getAccount(cmdBuilder, args[0]); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			limit, _ := cmd.Flags().GetInt("limit")
+			mode, _ := cmd.Flags().GetString("mode")
+			outcome, _ := cmd.Flags().GetString("outcome")
+			if outcome != "" && !IsStringInSlice(outcome, MATCH_OUTCOMES) {
+				return fmt.Errorf("Outcome %v is invalid. Available outcomes: %+v", outcome, MATCH_OUTCOMES)
+			}
+
+			filter := &MatchHistoryFilter{
+				UserID:          account.User.Id,
+				MatchProfile:    mode,
+				Outcome:         outcome,
+				MaxResultsCount: limit,
+			}
+			if filter.DateTimeFrom, err = parseDateFlag(cmd, "from"); err != nil {
+				return err
+			}
+			if filter.DateTimeTo, err = parseDateFlag(cmd, "to"); err != nil {
+				return err
+			}
+			if !filter.DateTimeTo.IsZero() {
+				filter.DateTimeTo = filter.DateTimeTo.Add(24 * time.Hour)
+			}
+
+			opponent, _ := cmd.Flags().GetString("opponent")
+			if opponent != "" {
+				opponentAccount, err := getAccount(cmdBuilder, opponent)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				filter.OpponentUserID = opponentAccount.User.Id
+			}
+
+			cursor, _ := cmd.Flags().GetString("cursor")
+			matchStateList, nextCursor, err := getArchivedMatchStateList(cmdBuilder, filter, cursor)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(matchStateList) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("No finished matches found for <@%v>", account.CustomId))
+				return nil
+			}
+
+			msg := fmt.Sprintf("> Match history of <@%v>:\n", account.CustomId)
+			for _, matchState := range matchStateList {
+				msg += PrintMatchSummary(NewMatchSummary(account.User.Id, matchState))
+			}
+			if nextCursor != "" {
+				msg += fmt.Sprintf("> More matches: **dl history <@%v> --cursor %v**\n", account.CustomId, nextCursor)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	cmd.Flags().StringP("match", "m", "", "Show the details of the archived match by the MatchID")
+	cmd.Flags().StringP("mode", "", "", fmt.Sprintf("Filter by the match mode: %+v", CAPTAIN_DRAFT_MODES))
+	cmd.Flags().StringP("opponent", "o", "", "Filter by the opponent discord username#1234, @username or <@discord_user_id>")
+	cmd.Flags().StringP("outcome", "", "", fmt.Sprintf("Filter by the outcome: %+v", MATCH_OUTCOMES))
+	cmd.Flags().StringP("from", "", "", "Filter the matches started on or after the date: "+DATE_LAYOUT)
+	cmd.Flags().StringP("to", "", "", "Filter the matches started on or before the date: "+DATE_LAYOUT)
+	cmd.Flags().StringP("cursor", "c", "", "Cursor to continue browsing from")
+	cmd.Flags().IntP("limit", "l", DEFAULT_HISTORY_LIMIT, "Number of matches to show")
+	return cmd
+}
@@ -0,0 +1,912 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/challenge-league/nakama-go/context"
+	"github.com/gofrs/uuid"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"open-match.dev/open-match/pkg/pb"
+)
+
+const (
+	BRACKET_COLLECTION = "bracket_data"
+
+	BRACKET_FORMAT_SINGLE_ELIMINATION = "single"
+	BRACKET_FORMAT_DOUBLE_ELIMINATION = "double"
+
+	BRACKET_WINNERS     = "W"
+	BRACKET_LOSERS      = "L"
+	BRACKET_GRAND_FINAL = "GF"
+	// the grand final is played again when the winners bracket finalist
+	// loses it since they have not lost before
+	BRACKET_GRAND_FINAL_RESET = "GF2"
+
+	MATCH_EXTENSION_TOURNAMENT_ID = "tournament_id"
+)
+
+var BRACKET_FORMATS = []string{BRACKET_FORMAT_SINGLE_ELIMINATION, BRACKET_FORMAT_DOUBLE_ELIMINATION}
+
+type BracketParticipant struct {
+	UserID    string
+	DiscordID string
+	Username  string
+	Rating    float64
+	Seed      int
+}
+
+// BracketSlot is settled once the match feeding it is decided, a settled
+// slot without a user is a bye
+type BracketSlot struct {
+	UserID  string
+	Settled bool
+}
+
+type BracketMatch struct {
+	ID           string
+	Bracket      string
+	Round        int
+	Position     int
+	Slots        []*BracketSlot
+	MatchID      string
+	Decided      bool
+	WinnerUserID string
+	LoserUserID  string
+	WinnerTo     string
+	WinnerSlot   int
+	LoserTo      string
+	LoserSlot    int
+}
+
+type Bracket struct {
+	TournamentID   string
+	Title          string
+	Format         string
+	MatchProfile   string
+	Duration       int
+	Participants   []*BracketParticipant
+	Matches        []*BracketMatch
+	ChampionUserID string
+	DateTimeCreate time.Time
+}
+
+// BracketUpdateRequest writes the bracket if nobody has changed it since it
+// was read, the version * creates a new bracket
+type BracketUpdateRequest struct {
+	Bracket *Bracket
+	Version string
+}
+
+// SeedParticipants orders the participants by rating, the best is seed 1
+func SeedParticipants(participants []*BracketParticipant) {
+	sort.SliceStable(participants, func(i, j int) bool {
+		return participants[i].Rating > participants[j].Rating
+	})
+	for i, participant := range participants {
+		participant.Seed = i + 1
+	}
+}
+
+// GetBracketSeedOrder returns the seeds in the order of the first round
+// slots so that the top seeds can only meet in the late rounds, e.g.
+// 1 8 4 5 2 7 3 6 for 8 slots
+func GetBracketSeedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		var next []int
+		for _, seed := range order {
+			next = append(next, seed, 2*len(order)+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+func getBracketSize(count int) (size int, rounds int) {
+	size = 1
+	for size < count {
+		size *= 2
+		rounds += 1
+	}
+	return size, rounds
+}
+
+func getBracketMatchID(bracket string, round int, position int) string {
+	if bracket == BRACKET_GRAND_FINAL && round > 1 {
+		return BRACKET_GRAND_FINAL_RESET
+	}
+	if bracket == BRACKET_GRAND_FINAL {
+		return BRACKET_GRAND_FINAL
+	}
+	return fmt.Sprintf("%v%v-%v", bracket, round, position+1)
+}
+
+func newBracketMatch(bracket string, round int, position int) *BracketMatch {
+	return &BracketMatch{
+		ID:       getBracketMatchID(bracket, round, position),
+		Bracket:  bracket,
+		Round:    round,
+		Position: position,
+		Slots:    []*BracketSlot{&BracketSlot{}, &BracketSlot{}},
+	}
+}
+
+// NewBracket seeds the participants and lays out all the matches of the
+// bracket, the losers of the winners bracket drop to the losers bracket in
+// the double elimination and the grand final is reset if its losers bracket
+// finalist wins
+func NewBracket(tournamentID string, title string, format string, participants []*BracketParticipant, duration int) (*Bracket, error) {
+	if !IsStringInSlice(format, BRACKET_FORMATS) {
+		return nil, fmt.Errorf("Bracket format %v is invalid. Available formats: %+v", format, BRACKET_FORMATS)
+	}
+	minParticipants := 2
+	if format == BRACKET_FORMAT_DOUBLE_ELIMINATION {
+		minParticipants = 3
+	}
+	if len(participants) < minParticipants {
+		return nil, fmt.Errorf("The %v elimination bracket needs at least %v participants, %v registered", format, minParticipants, len(participants))
+	}
+
+	SeedParticipants(participants)
+	bracket := &Bracket{
+		TournamentID:   tournamentID,
+		Title:          title,
+		Format:         format,
+		MatchProfile:   MATCH_PROFILE_1_VS_1,
+		Duration:       duration,
+		Participants:   participants,
+		DateTimeCreate: time.Now().UTC(),
+	}
+
+	size, rounds := getBracketSize(len(participants))
+	seedOrder := GetBracketSeedOrder(size)
+	for round := 1; round <= rounds; round++ {
+		for position := 0; position < size>>round; position++ {
+			match := newBracketMatch(BRACKET_WINNERS, round, position)
+			if round == 1 {
+				for slot := 0; slot < 2; slot++ {
+					match.Slots[slot].Settled = true
+					if seed := seedOrder[2*position+slot]; seed <= len(participants) {
+						match.Slots[slot].UserID = participants[seed-1].UserID
+					}
+				}
+			}
+			if round < rounds {
+				match.WinnerTo, match.WinnerSlot = getBracketMatchID(BRACKET_WINNERS, round+1, position/2), position%2
+			}
+			bracket.Matches = append(bracket.Matches, match)
+		}
+	}
+	if format == BRACKET_FORMAT_DOUBLE_ELIMINATION {
+		bracket.addLosersBracket(size, rounds)
+	}
+	bracket.ResolveByes()
+	return bracket, nil
+}
+
+func (b *Bracket) addLosersBracket(size int, rounds int) {
+	losersRounds := 2 * (rounds - 1)
+	count := size / 4
+	for round := 1; round <= losersRounds; round++ {
+		if round > 1 && round%2 == 1 {
+			count /= 2
+		}
+		for position := 0; position < count; position++ {
+			match := newBracketMatch(BRACKET_LOSERS, round, position)
+			switch {
+			case round == losersRounds:
+				match.WinnerTo, match.WinnerSlot = BRACKET_GRAND_FINAL, 1
+			case round%2 == 1:
+				match.WinnerTo, match.WinnerSlot = getBracketMatchID(BRACKET_LOSERS, round+1, position), 0
+			default:
+				match.WinnerTo, match.WinnerSlot = getBracketMatchID(BRACKET_LOSERS, round+1, position/2), position%2
+			}
+			b.Matches = append(b.Matches, match)
+		}
+	}
+
+	for _, match := range b.Matches {
+		if match.Bracket != BRACKET_WINNERS {
+			continue
+		}
+		if match.Round == 1 {
+			match.LoserTo, match.LoserSlot = getBracketMatchID(BRACKET_LOSERS, 1, match.Position/2), match.Position%2
+		} else {
+			// the drop-downs are crossed over to delay the rematches
+			count := size >> match.Round
+			match.LoserTo, match.LoserSlot = getBracketMatchID(BRACKET_LOSERS, 2*(match.Round-1), count-1-match.Position), 1
+		}
+		if match.Round == rounds {
+			match.WinnerTo, match.WinnerSlot = BRACKET_GRAND_FINAL, 0
+		}
+	}
+	// the winners bracket finalist keeps slot 0 in the reset
+	final := newBracketMatch(BRACKET_GRAND_FINAL, 1, 0)
+	final.WinnerTo, final.WinnerSlot = BRACKET_GRAND_FINAL_RESET, 1
+	final.LoserTo, final.LoserSlot = BRACKET_GRAND_FINAL_RESET, 0
+	b.Matches = append(b.Matches, final, newBracketMatch(BRACKET_GRAND_FINAL, 2, 0))
+}
+
+func (b *Bracket) GetMatch(id string) *BracketMatch {
+	for _, match := range b.Matches {
+		if match.ID == id {
+			return match
+		}
+	}
+	return nil
+}
+
+func (b *Bracket) GetMatchByMatchID(matchID string) *BracketMatch {
+	for _, match := range b.Matches {
+		if match.MatchID == matchID {
+			return match
+		}
+	}
+	return nil
+}
+
+func (b *Bracket) GetParticipant(userID string) *BracketParticipant {
+	for _, participant := range b.Participants {
+		if participant.UserID == userID {
+			return participant
+		}
+	}
+	return nil
+}
+
+func (b *Bracket) settle(matchID string, slot int, userID string) {
+	if match := b.GetMatch(matchID); match != nil {
+		match.Slots[slot].UserID = userID
+		match.Slots[slot].Settled = true
+	}
+}
+
+// Decide records the winner of the match and moves the winner and the loser
+// to their next matches
+func (b *Bracket) Decide(match *BracketMatch, winnerUserID string) {
+	match.Decided = true
+	match.WinnerUserID = winnerUserID
+	for _, slot := range match.Slots {
+		if slot.UserID != winnerUserID {
+			match.LoserUserID = slot.UserID
+		}
+	}
+	if match.ID == BRACKET_GRAND_FINAL && match.WinnerTo != "" && winnerUserID == match.Slots[0].UserID {
+		// the winners bracket finalist is still unbeaten, the reset is not
+		// played
+		if reset := b.GetMatch(match.WinnerTo); reset != nil {
+			reset.Decided = true
+		}
+		b.ChampionUserID = match.WinnerUserID
+		return
+	}
+	if match.WinnerTo != "" {
+		b.settle(match.WinnerTo, match.WinnerSlot, match.WinnerUserID)
+	} else {
+		b.ChampionUserID = match.WinnerUserID
+	}
+	if match.LoserTo != "" {
+		b.settle(match.LoserTo, match.LoserSlot, match.LoserUserID)
+	}
+}
+
+func (m *BracketMatch) IsReady() bool {
+	return !m.Decided && m.Slots[0].Settled && m.Slots[1].Settled
+}
+
+func (m *BracketMatch) HasOpponents() bool {
+	return m.Slots[0].UserID != "" && m.Slots[1].UserID != ""
+}
+
+func (m *BracketMatch) IsPlayable() bool {
+	return m.IsReady() && m.HasOpponents()
+}
+
+// ResolveByes advances the participants without an opponent until every
+// remaining match needs to be played
+func (b *Bracket) ResolveByes() {
+	for changed := true; changed; {
+		changed = false
+		for _, match := range b.Matches {
+			if !match.IsReady() || match.IsPlayable() {
+				continue
+			}
+			winnerUserID := match.Slots[0].UserID
+			if winnerUserID == "" {
+				winnerUserID = match.Slots[1].UserID
+			}
+			b.Decide(match, winnerUserID)
+			changed = true
+		}
+	}
+}
+
+// AdvanceFromMatchState decides the bracket match from the finalised match,
+// the cancelled and the drawn matches are created again since an elimination
+// match needs a winner
+func (b *Bracket) AdvanceFromMatchState(match *BracketMatch, matchState *MatchState) bool {
+	if matchState.Status == MATCH_STATUS_CANCELED {
+		match.MatchID = ""
+		return true
+	}
+	winner := GetMatchWinnerTeamNumber(matchState)
+	if winner == MATCH_RESULT_TEAM_DRAW {
+		match.MatchID = ""
+		return true
+	}
+	if winner < 0 {
+		return false
+	}
+	for _, slot := range match.Slots {
+		if GetTeamNumberFromUserAndMatch(slot.UserID, matchState) == winner {
+			b.Decide(match, slot.UserID)
+			b.ResolveByes()
+			return true
+		}
+	}
+	return false
+}
+
+// DecideByUser decides the playable match for one of its players
+func (b *Bracket) DecideByUser(match *BracketMatch, winnerUserID string) error {
+	if !match.IsPlayable() {
+		return fmt.Errorf("The bracket match **%v** is not waiting for a result", match.ID)
+	}
+	if match.Slots[0].UserID != winnerUserID && match.Slots[1].UserID != winnerUserID {
+		return fmt.Errorf("The winner is not a player of the bracket match **%v**", match.ID)
+	}
+	b.Decide(match, winnerUserID)
+	b.ResolveByes()
+	return nil
+}
+
+func GetBracketRoundName(bracket *Bracket, match *BracketMatch) string {
+	_, rounds := getBracketSize(len(bracket.Participants))
+	switch {
+	case match.ID == BRACKET_GRAND_FINAL_RESET:
+		return "Grand final reset"
+	case match.Bracket == BRACKET_GRAND_FINAL:
+		return "Grand final"
+	case match.Bracket == BRACKET_LOSERS:
+		if match.Round == 2*(rounds-1) {
+			return "Losers final"
+		}
+		return fmt.Sprintf("Losers round %v", match.Round)
+	case match.Round == rounds && bracket.Format == BRACKET_FORMAT_DOUBLE_ELIMINATION:
+		return "Winners final"
+	case match.Round == rounds:
+		return "Final"
+	case bracket.Format == BRACKET_FORMAT_DOUBLE_ELIMINATION:
+		return fmt.Sprintf("Winners round %v", match.Round)
+	case match.Round == rounds-1:
+		return "Semifinals"
+	default:
+		return fmt.Sprintf("Round %v", match.Round)
+	}
+}
+
+func PrintBracketSlot(bracket *Bracket, slot *BracketSlot) string {
+	switch {
+	case !slot.Settled:
+		return "TBD"
+	case slot.UserID == "":
+		return "bye"
+	}
+	participant := bracket.GetParticipant(slot.UserID)
+	if participant == nil {
+		return slot.UserID
+	}
+	return fmt.Sprintf("<@%v> (#%v)", participant.DiscordID, participant.Seed)
+}
+
+func PrintBracketMatch(bracket *Bracket, match *BracketMatch) string {
+	msg := fmt.Sprintf("`%v` %v vs %v", match.ID, PrintBracketSlot(bracket, match.Slots[0]), PrintBracketSlot(bracket, match.Slots[1]))
+	switch {
+	case match.Decided && match.HasOpponents():
+		msg += fmt.Sprintf(" won by **%v**", PrintBracketSlot(bracket, &BracketSlot{UserID: match.WinnerUserID, Settled: true}))
+	case match.Decided && !match.Slots[0].Settled:
+		msg += " not played"
+	case match.Decided:
+	case match.MatchID != "":
+		msg += fmt.Sprintf(" match **%v**", match.MatchID)
+	case match.IsPlayable():
+		msg += " waiting for the players"
+	}
+	return msg
+}
+
+// getBracketRounds groups the matches by round keeping the bracket order
+func getBracketRounds(bracket *Bracket) ([]string, map[string][]*BracketMatch) {
+	var names []string
+	rounds := map[string][]*BracketMatch{}
+	for _, match := range bracket.Matches {
+		name := GetBracketRoundName(bracket, match)
+		if _, ok := rounds[name]; !ok {
+			names = append(names, name)
+		}
+		rounds[name] = append(rounds[name], match)
+	}
+	return names, rounds
+}
+
+func PrintBracketTitle(bracket *Bracket) string {
+	return fmt.Sprintf("Bracket **%v**, %v elimination, %v players", bracket.Title, bracket.Format, len(bracket.Participants))
+}
+
+func PrintBracket(bracket *Bracket) string {
+	msg := "> " + PrintBracketTitle(bracket) + "\n"
+	names, rounds := getBracketRounds(bracket)
+	for _, name := range names {
+		msg += fmt.Sprintf("> **%v**\n", name)
+		for _, match := range rounds[name] {
+			msg += "> " + PrintBracketMatch(bracket, match) + "\n"
+		}
+	}
+	if bracket.ChampionUserID != "" {
+		msg += fmt.Sprintf("> Champion: **%v**\n", PrintBracketSlot(bracket, &BracketSlot{UserID: bracket.ChampionUserID, Settled: true}))
+	}
+	return msg
+}
+
+func PrintBracketEmbed(bracket *Bracket) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Color:       0x00ff00,
+		Title:       bracket.Title,
+		Description: PrintBracketTitle(bracket),
+	}
+	names, rounds := getBracketRounds(bracket)
+	for _, name := range names {
+		var lines []string
+		for _, match := range rounds[name] {
+			lines = append(lines, PrintBracketMatch(bracket, match))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  name,
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+	if bracket.ChampionUserID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Champion",
+			Value: PrintBracketSlot(bracket, &BracketSlot{UserID: bracket.ChampionUserID, Settled: true}),
+		})
+	}
+	return embed
+}
+
+func getTournamentRecordList(cmdBuilder *commandsBuilder, tournamentID string) ([]*api.LeaderboardRecord, error) {
+	var records []*api.LeaderboardRecord
+	request := &api.ListTournamentRecordsRequest{
+		TournamentId: tournamentID,
+		Limit:        &wrapperspb.Int32Value{Value: MAX_LIST_LIMIT},
+	}
+	for {
+		result, err := cmdBuilder.nakamaCtx.Client.ListTournamentRecords(cmdBuilder.nakamaCtx.Ctx, request)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		records = append(records, result.GetRecords()...)
+		if result.GetNextCursor() == "" || len(result.GetRecords()) == 0 {
+			return records, nil
+		}
+		request.Cursor = result.GetNextCursor()
+	}
+}
+
+// getTournamentParticipantIDs returns the checked-in users of the tournaments
+// with the registration and the joined users of the others
+func getTournamentParticipantIDs(cmdBuilder *commandsBuilder, tournamentID string) ([]string, error) {
+	tournamentRegistration, _, err := getFinalizedTournamentRegistration(cmdBuilder, tournamentID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if tournamentRegistration != nil {
+		if !tournamentRegistration.Finalized {
+			return nil, fmt.Errorf("The check-in is open until %v", formatTimeAsDate(tournamentRegistration.DateTimeStart))
+		}
+		return tournamentRegistration.GetParticipantIDs(), nil
+	}
+
+	records, err := getTournamentRecordList(cmdBuilder, tournamentID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var userIDs []string
+	for _, record := range records {
+		userIDs = append(userIDs, record.GetOwnerId())
+	}
+	return userIDs, nil
+}
+
+// getBracketParticipants rates the participants by their score on the main
+// leaderboard
+func getBracketParticipants(cmdBuilder *commandsBuilder, userIDs []string) ([]*BracketParticipant, error) {
+	accounts, err := getAccountList(cmdBuilder, userIDs)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	codec, err := getScoreCodec(cmdBuilder, MAIN_LEADERBOARD)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	ratings := map[string]float64{}
+	for start := 0; start < len(userIDs); start += MAX_LIST_LIMIT {
+		end := start + MAX_LIST_LIMIT
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		result, err := cmdBuilder.nakamaCtx.Client.ListLeaderboardRecords(cmdBuilder.nakamaCtx.Ctx, &api.ListLeaderboardRecordsRequest{
+			LeaderboardId: MAIN_LEADERBOARD,
+			OwnerIds:      userIDs[start:end],
+			Limit:         &wrapperspb.Int32Value{Value: 1},
+		})
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		for _, record := range result.GetOwnerRecords() {
+			ratings[record.GetOwnerId()] = codec.Float(record.GetScore(), record.GetSubscore())
+		}
+	}
+
+	var participants []*BracketParticipant
+	for _, account := range accounts {
+		participants = append(participants, &BracketParticipant{
+			UserID:    account.User.Id,
+			DiscordID: account.CustomId,
+			Username:  account.User.Username,
+			Rating:    ratings[account.User.Id],
+		})
+	}
+	return participants, nil
+}
+
+func getBracket(cmdBuilder *commandsBuilder, tournamentID string) (*Bracket, string, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, BRACKET_COLLECTION, tournamentID, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	if len(storageObjects) == 0 {
+		return nil, "", nil
+	}
+	var bracket *Bracket
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &bracket); err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	return bracket, storageObjects[0].Version, nil
+}
+
+// updateBracket writes the bracket if nobody has changed it since it was read
+func updateBracket(cmdBuilder *commandsBuilder, bracket *Bracket, version string) error {
+	payload, _ := json.Marshal(&BracketUpdateRequest{
+		Bracket: bracket,
+		Version: version,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "BracketUpdate", Payload: string(payload)}); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// createTournamentMatch creates the captains draft match claimed by the
+// tournament with the given teams, the match is not created while a player
+// still has a ticket and the server refuses a match ID which exists already
+func createTournamentMatch(cmdBuilder *commandsBuilder, matchID string, tournamentID string, matchProfile string, duration int, teams []*Team) (bool, error) {
+	var accounts []*api.Account
+	for _, team := range teams {
+		for _, teamUser := range team.TeamUsers {
+			account, err := getAccountByDiscordID(cmdBuilder, teamUser.User.Nakama.CustomID)
+			if err != nil {
+				log.Error(err)
+				return false, err
+			}
+			lastTicketState, err := getLastUserTicketState(cmdBuilder, account)
+			if err != nil {
+				log.Error(err)
+				return false, err
+			}
+			if lastTicketState != nil {
+				log.Infof("%v already has the ticket %v", account.CustomId, lastTicketState.Ticket.Id)
+				return false, nil
+			}
+			accounts = append(accounts, account)
+		}
+	}
+
+	leaderboardID := GetMatchLeaderboardID(matchProfile, MATCH_TYPE_CAPTAINS_DRAFT, time.Duration(duration)*time.Hour)
+	if err := ensureLeaderboard(cmdBuilder, leaderboardID); err != nil {
+		log.Error(err)
+		return false, err
+	}
+	seasonLeaderboardID, err := getCurrentSeasonLeaderboardID(cmdBuilder)
+	if err != nil {
+		log.Error(err)
+		return false, err
+	}
+
+	var tickets []*pb.Ticket
+	for _, account := range accounts {
+		ticketState, err := newCaptainsDraftTicketState(cmdBuilder, account, matchID, false, duration, []string{matchProfile})
+		if err != nil {
+			log.Error(err)
+			return false, err
+		}
+		if teamUser, _ := GetUserAndTeamNumberByUserID(account.User.Id, &MatchState{Teams: teams}); teamUser != nil {
+			teamUser.TicketID = ticketState.Ticket.Id
+		}
+		tickets = append(tickets, ticketState.Ticket)
+	}
+
+	match := &pb.Match{
+		MatchId:      matchID,
+		MatchProfile: matchProfile,
+		Tickets:      tickets,
+		Extensions: map[string]*anypb.Any{
+			MATCH_EXTENSION_MATCH_TYPE:            &anypb.Any{Value: Marshal(MATCH_TYPE_CAPTAINS_DRAFT)},
+			MATCH_EXTENSION_TEAMS:                 &anypb.Any{Value: Marshal(teams)},
+			MATCH_EXTENSION_ROUTED_LEADERBOARD_ID: &anypb.Any{Value: Marshal(leaderboardID)},
+			MATCH_EXTENSION_TOURNAMENT_ID:         &anypb.Any{Value: Marshal(tournamentID)},
+		},
+	}
+	if seasonLeaderboardID != "" {
+		match.Extensions[MATCH_EXTENSION_SEASON_LEADERBOARD_ID] = &anypb.Any{Value: Marshal(seasonLeaderboardID)}
+	}
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchCreate", Payload: string(Marshal(match))})
+	if err != nil {
+		log.Error(err)
+		return false, err
+	}
+	log.Infof("%+v\n", MarshalIndent(result))
+	return true, nil
+}
+
+func NewBracketMatchTeams(bracket *Bracket, match *BracketMatch) []*Team {
+	var teams []*Team
+	for i, slot := range match.Slots {
+		participant := bracket.GetParticipant(slot.UserID)
+		teams = append(teams, &Team{
+			ID: i,
+			TeamUsers: []*TeamUser{
+				&TeamUser{
+					User: &User{
+						Nakama: &NakamaUser{
+							CustomID: participant.DiscordID,
+							ID:       participant.UserID,
+							Username: participant.Username,
+						},
+					},
+					Captain: true,
+				},
+			},
+		})
+	}
+	return teams
+}
+
+// syncBracket advances the winners of the finalised matches and creates the
+// matches which are ready to be played. The new matches are claimed in the
+// bracket by the versioned write before they are created so that a lost race
+// creates nothing, the claimed matches which are not created yet, e.g. while
+// a player still has a ticket, are created by the next sync
+func syncBracket(cmdBuilder *commandsBuilder, bracket *Bracket, version string, changed bool) error {
+	var pendingMatches []*BracketMatch
+	for _, match := range bracket.Matches {
+		if !match.IsPlayable() || match.MatchID == "" {
+			continue
+		}
+		matchState, err := getMatchState(cmdBuilder, match.MatchID, MATCH_ARCHIVE_COLLECTION)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		if matchState != nil {
+			if bracket.AdvanceFromMatchState(match, matchState) {
+				changed = true
+			}
+			continue
+		}
+		if matchState, err = getMatchState(cmdBuilder, match.MatchID, MATCH_COLLECTION); err != nil {
+			log.Error(err)
+			return err
+		}
+		if matchState == nil {
+			pendingMatches = append(pendingMatches, match)
+		}
+	}
+
+	for _, match := range bracket.Matches {
+		if !match.IsPlayable() || match.MatchID != "" {
+			continue
+		}
+		match.MatchID = uuid.Must(uuid.NewV4()).String()
+		pendingMatches = append(pendingMatches, match)
+		changed = true
+	}
+
+	if changed {
+		if err := updateBracket(cmdBuilder, bracket, version); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	for _, match := range pendingMatches {
+		if _, err := createTournamentMatch(cmdBuilder, match.MatchID, bracket.TournamentID, bracket.MatchProfile, bracket.Duration, NewBracketMatchTeams(bracket, match)); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func getCmdBracketCreate(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create [tournament]",
+		Short: "Create the **bracket** of the tournament",
+		Long: `Create the single or double elimination **bracket** of the tournament
+The participants are seeded by their rating, the top seeds get the byes`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			duration, _ := cmd.Flags().GetInt("duration")
+			if duration < MIN_MATCH_DURATION_HOURS || duration > MAX_MATCH_DURATION_HOURS {
+				return fmt.Errorf("duration must be between %v and %v hours", MIN_MATCH_DURATION_HOURS, MAX_MATCH_DURATION_HOURS)
+			}
+			format, _ := cmd.Flags().GetString("format")
+
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if bracket, _, err := getBracket(cmdBuilder, tournament.Id); err != nil {
+				log.Error(err)
+				return err
+			} else if bracket != nil {
+				return fmt.Errorf("The tournament **%v** already has a bracket", tournament.Title)
+			}
+
+			userIDs, err := getTournamentParticipantIDs(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			participants, err := getBracketParticipants(cmdBuilder, userIDs)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			bracket, err := NewBracket(tournament.Id, tournament.Title, format, participants, duration)
+			if err != nil {
+				return err
+			}
+			if err := syncBracket(cmdBuilder, bracket, "*", true); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintBracket(bracket))
+			return nil
+		},
+	}
+	cmd.Flags().StringP("format", "f", BRACKET_FORMAT_SINGLE_ELIMINATION, fmt.Sprintf("Bracket format: %+v", BRACKET_FORMATS))
+	cmd.Flags().IntP("duration", "d", DEFAULT_MATCH_DURATION_HOURS, "Duration of the bracket matches in hours")
+	return cmd
+}
+
+func getCmdBracket(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bracket [tournament]",
+		Short: "Show the **bracket** of the tournament",
+		Long: `Show the **bracket** of the tournament
+The winners of the finished matches advance and the next matches are created automatically`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			bracket, version, err := getBracket(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if bracket == nil {
+				return fmt.Errorf("The tournament **%v** has no bracket yet", tournament.Title)
+			}
+			if err := syncBracket(cmdBuilder, bracket, version, false); err != nil {
+				log.Error(err)
+				return err
+			}
+			if embed, _ := cmd.Flags().GetBool("embed"); embed {
+				cmdBuilder.AddEmbed(PrintBracketEmbed(bracket))
+				fmt.Fprint(cmd.OutOrStdout(), "> "+PrintBracketTitle(bracket)+"\n")
+				return nil
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintBracket(bracket))
+			return nil
+		},
+	}
+	cmd.Flags().BoolP("embed", "e", false, "Send the bracket as a Discord embed")
+	return cmd
+}
+
+func getCmdBracketDecide(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decide [tournament] [match] [winner]",
+		Short: "Decide a **bracket match** by hand",
+		Long: `Decide a **bracket match** by hand, e.g. dl bracket decide cup W2-1 @username
+The winner advances and the loser drops to the losers bracket or is eliminated, use it for the matches which can not be finished`,
+		Args: matchAll(cobra.ExactArgs(3)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			bracket, version, err := getBracket(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if bracket == nil {
+				return fmt.Errorf("The tournament **%v** has no bracket yet", tournament.Title)
+			}
+			match := bracket.GetMatch(args[1])
+			if match == nil {
+				return fmt.Errorf("The bracket match **%v** not found", args[1])
+			}
+			account, err := getAccount(cmdBuilder, args[2])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if err := bracket.DecideByUser(match, account.User.Id); err != nil {
+				return err
+			}
+			if err := syncBracket(cmdBuilder, bracket, version, true); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintBracket(bracket))
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,324 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func newTestBracketParticipants(count int) []*BracketParticipant {
+	var participants []*BracketParticipant
+	for i := 1; i <= count; i++ {
+		participants = append(participants, &BracketParticipant{
+			UserID:    fmt.Sprintf("user%v", i),
+			DiscordID: fmt.Sprintf("%v", i),
+			Rating:    float64(100 - i),
+		})
+	}
+	// the seeding must not depend on the registration order
+	for i, j := 0, len(participants)-1; i < j; i, j = i+1, j-1 {
+		participants[i], participants[j] = participants[j], participants[i]
+	}
+	return participants
+}
+
+// decideBracketBySeed plays the bracket until the champion is known, the
+// better seed wins every match
+func decideBracketBySeed(t *testing.T, bracket *Bracket) {
+	for played := true; played; {
+		played = false
+		for _, match := range bracket.Matches {
+			if !match.IsPlayable() {
+				continue
+			}
+			winner := match.Slots[0].UserID
+			if bracket.GetParticipant(match.Slots[1].UserID).Seed < bracket.GetParticipant(winner).Seed {
+				winner = match.Slots[1].UserID
+			}
+			if err := bracket.DecideByUser(match, winner); err != nil {
+				t.Fatal(err)
+			}
+			played = true
+		}
+	}
+}
+
+func TestGetBracketSeedOrder(t *testing.T) {
+	tests := []struct {
+		size  int
+		order []int
+	}{
+		{1, []int{1}},
+		{2, []int{1, 2}},
+		{4, []int{1, 4, 2, 3}},
+		{8, []int{1, 8, 4, 5, 2, 7, 3, 6}},
+	}
+	for _, test := range tests {
+		if order := GetBracketSeedOrder(test.size); !reflect.DeepEqual(order, test.order) {
+			t.Errorf("GetBracketSeedOrder(%v) = %v, want %v", test.size, order, test.order)
+		}
+	}
+}
+
+func TestSeedParticipants(t *testing.T) {
+	participants := []*BracketParticipant{
+		&BracketParticipant{UserID: "a", Rating: 10},
+		&BracketParticipant{UserID: "b", Rating: 30},
+		&BracketParticipant{UserID: "c", Rating: 20},
+		&BracketParticipant{UserID: "d", Rating: 30},
+	}
+	SeedParticipants(participants)
+	var userIDs []string
+	for i, participant := range participants {
+		if participant.Seed != i+1 {
+			t.Errorf("%v has the seed %v, want %v", participant.UserID, participant.Seed, i+1)
+		}
+		userIDs = append(userIDs, participant.UserID)
+	}
+	if want := []string{"b", "d", "c", "a"}; !reflect.DeepEqual(userIDs, want) {
+		t.Errorf("SeedParticipants() = %v, want %v", userIDs, want)
+	}
+}
+
+func TestNewBracketByes(t *testing.T) {
+	tests := []struct {
+		format   string
+		count    int
+		byes     []string
+		playable []string
+	}{
+		{BRACKET_FORMAT_SINGLE_ELIMINATION, 2, nil, []string{"W1-1"}},
+		{BRACKET_FORMAT_SINGLE_ELIMINATION, 3, []string{"W1-1"}, []string{"W1-2"}},
+		{BRACKET_FORMAT_SINGLE_ELIMINATION, 5, []string{"W1-1", "W1-3", "W1-4"}, []string{"W1-2", "W2-2"}},
+		{BRACKET_FORMAT_SINGLE_ELIMINATION, 6, []string{"W1-1", "W1-3"}, []string{"W1-2", "W1-4"}},
+		{BRACKET_FORMAT_SINGLE_ELIMINATION, 8, nil, []string{"W1-1", "W1-2", "W1-3", "W1-4"}},
+		{BRACKET_FORMAT_DOUBLE_ELIMINATION, 3, []string{"W1-1"}, []string{"W1-2"}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v %v", test.format, test.count), func(t *testing.T) {
+			bracket, err := NewBracket("tournament", "Cup", test.format, newTestBracketParticipants(test.count), DEFAULT_MATCH_DURATION_HOURS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			size, _ := getBracketSize(test.count)
+			var byes, playable []string
+			for _, match := range bracket.Matches {
+				if match.Decided && match.Round == 1 && match.Bracket != BRACKET_GRAND_FINAL {
+					byes = append(byes, match.ID)
+					if match.WinnerUserID != "" && bracket.GetParticipant(match.WinnerUserID).Seed > size-test.count {
+						t.Errorf("the seed %v got a bye in %v", bracket.GetParticipant(match.WinnerUserID).Seed, match.ID)
+					}
+				}
+				if match.IsPlayable() {
+					playable = append(playable, match.ID)
+				}
+			}
+			if !reflect.DeepEqual(byes, test.byes) {
+				t.Errorf("byes = %v, want %v", byes, test.byes)
+			}
+			if !reflect.DeepEqual(playable, test.playable) {
+				t.Errorf("playable = %v, want %v", playable, test.playable)
+			}
+		})
+	}
+}
+
+func TestNewBracketTooFewParticipants(t *testing.T) {
+	tests := []struct {
+		format string
+		count  int
+	}{
+		{BRACKET_FORMAT_SINGLE_ELIMINATION, 1},
+		{BRACKET_FORMAT_DOUBLE_ELIMINATION, 2},
+		{"swiss", 8},
+	}
+	for _, test := range tests {
+		if _, err := NewBracket("tournament", "Cup", test.format, newTestBracketParticipants(test.count), DEFAULT_MATCH_DURATION_HOURS); err == nil {
+			t.Errorf("NewBracket(%v, %v) expected an error", test.format, test.count)
+		}
+	}
+}
+
+func TestDoubleEliminationRouting(t *testing.T) {
+	bracket, err := NewBracket("tournament", "Cup", BRACKET_FORMAT_DOUBLE_ELIMINATION, newTestBracketParticipants(8), DEFAULT_MATCH_DURATION_HOURS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		id         string
+		winnerTo   string
+		winnerSlot int
+		loserTo    string
+		loserSlot  int
+	}{
+		{"W1-1", "W2-1", 0, "L1-1", 0},
+		{"W1-2", "W2-1", 1, "L1-1", 1},
+		{"W1-3", "W2-2", 0, "L1-2", 0},
+		{"W1-4", "W2-2", 1, "L1-2", 1},
+		{"W2-1", "W3-1", 0, "L2-2", 1},
+		{"W2-2", "W3-1", 1, "L2-1", 1},
+		{"W3-1", BRACKET_GRAND_FINAL, 0, "L4-1", 1},
+		{"L1-1", "L2-1", 0, "", 0},
+		{"L1-2", "L2-2", 0, "", 0},
+		{"L2-1", "L3-1", 0, "", 0},
+		{"L2-2", "L3-1", 1, "", 0},
+		{"L3-1", "L4-1", 0, "", 0},
+		{"L4-1", BRACKET_GRAND_FINAL, 1, "", 0},
+		{BRACKET_GRAND_FINAL, BRACKET_GRAND_FINAL_RESET, 1, BRACKET_GRAND_FINAL_RESET, 0},
+		{BRACKET_GRAND_FINAL_RESET, "", 0, "", 0},
+	}
+	if len(bracket.Matches) != len(tests) {
+		t.Errorf("the bracket has %v matches, want %v", len(bracket.Matches), len(tests))
+	}
+	for _, test := range tests {
+		match := bracket.GetMatch(test.id)
+		if match == nil {
+			t.Errorf("the match %v not found", test.id)
+			continue
+		}
+		if match.WinnerTo != test.winnerTo || match.WinnerSlot != test.winnerSlot || match.LoserTo != test.loserTo || match.LoserSlot != test.loserSlot {
+			t.Errorf("%v routes the winner to %v/%v and the loser to %v/%v, want %v/%v and %v/%v", test.id,
+				match.WinnerTo, match.WinnerSlot, match.LoserTo, match.LoserSlot,
+				test.winnerTo, test.winnerSlot, test.loserTo, test.loserSlot)
+		}
+	}
+
+	decideBracketBySeed(t, bracket)
+	if bracket.ChampionUserID != "user1" {
+		t.Errorf("the champion is %v, want user1", bracket.ChampionUserID)
+	}
+	if final := bracket.GetMatch(BRACKET_GRAND_FINAL); final.Slots[1].UserID != "user2" {
+		t.Errorf("the losers bracket winner is %v, want user2", final.Slots[1].UserID)
+	}
+	if reset := bracket.GetMatch(BRACKET_GRAND_FINAL_RESET); !reset.Decided || reset.HasOpponents() {
+		t.Errorf("the grand final reset is played after the winners bracket finalist won the grand final")
+	}
+}
+
+func TestDoubleEliminationGrandFinalReset(t *testing.T) {
+	for _, winner := range []string{"user1", "user2"} {
+		t.Run(winner, func(t *testing.T) {
+			bracket, err := NewBracket("tournament", "Cup", BRACKET_FORMAT_DOUBLE_ELIMINATION, newTestBracketParticipants(4), DEFAULT_MATCH_DURATION_HOURS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, result := range []struct {
+				id     string
+				winner string
+			}{
+				{"W1-1", "user1"},
+				{"W1-2", "user2"},
+				{"W2-1", "user1"},
+				{"L1-1", "user3"},
+				{"L2-1", "user2"},
+				{BRACKET_GRAND_FINAL, "user2"},
+			} {
+				if err := bracket.DecideByUser(bracket.GetMatch(result.id), result.winner); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if bracket.ChampionUserID != "" {
+				t.Fatalf("the champion is %v after the first loss of the winners bracket finalist", bracket.ChampionUserID)
+			}
+			reset := bracket.GetMatch(BRACKET_GRAND_FINAL_RESET)
+			if !reset.IsPlayable() || reset.Slots[0].UserID != "user1" || reset.Slots[1].UserID != "user2" {
+				t.Fatalf("the grand final reset is %v vs %v, want user1 vs user2", reset.Slots[0].UserID, reset.Slots[1].UserID)
+			}
+			if err := bracket.DecideByUser(reset, winner); err != nil {
+				t.Fatal(err)
+			}
+			if bracket.ChampionUserID != winner {
+				t.Errorf("the champion is %v, want %v", bracket.ChampionUserID, winner)
+			}
+		})
+	}
+}
+
+func TestBracketAdvanceFromMatchState(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []*MatchResult
+		status  string
+		decided bool
+		winner  string
+		matchID string
+	}{
+		{
+			name: "win",
+			results: []*MatchResult{
+				&MatchResult{UserID: "user1", TeamNumber: 0, Win: true},
+				&MatchResult{UserID: "user2", TeamNumber: 0, Win: true},
+			},
+			decided: true,
+			winner:  "user1",
+			matchID: "match",
+		},
+		{
+			name: "draw",
+			results: []*MatchResult{
+				&MatchResult{UserID: "user1", TeamNumber: MATCH_RESULT_TEAM_DRAW, Draw: true},
+				&MatchResult{UserID: "user2", TeamNumber: MATCH_RESULT_TEAM_DRAW, Draw: true},
+			},
+		},
+		{
+			name:   "canceled",
+			status: MATCH_STATUS_CANCELED,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bracket, err := NewBracket("tournament", "Cup", BRACKET_FORMAT_SINGLE_ELIMINATION, newTestBracketParticipants(2), DEFAULT_MATCH_DURATION_HOURS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			match := bracket.GetMatch("W1-1")
+			match.MatchID = "match"
+			matchState := &MatchState{
+				Status:  test.status,
+				Teams:   NewBracketMatchTeams(bracket, match),
+				Results: test.results,
+			}
+			if !bracket.AdvanceFromMatchState(match, matchState) {
+				t.Fatal("AdvanceFromMatchState() = false, want true")
+			}
+			if match.Decided != test.decided || match.WinnerUserID != test.winner || match.MatchID != test.matchID {
+				t.Errorf("the match is decided %v by %v with the match %q, want decided %v by %v with the match %q",
+					match.Decided, match.WinnerUserID, match.MatchID, test.decided, test.winner, test.matchID)
+			}
+		})
+	}
+}
+
+func TestBracketDecideByUser(t *testing.T) {
+	bracket, err := NewBracket("tournament", "Cup", BRACKET_FORMAT_SINGLE_ELIMINATION, newTestBracketParticipants(3), DEFAULT_MATCH_DURATION_HOURS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bracket.DecideByUser(bracket.GetMatch("W2-1"), "user1"); err == nil {
+		t.Error("the final is decided before its players are known")
+	}
+	if err := bracket.DecideByUser(bracket.GetMatch("W1-2"), "user1"); err == nil {
+		t.Error("the match is decided for a user who does not play it")
+	}
+	if err := bracket.DecideByUser(bracket.GetMatch("W1-2"), "user3"); err != nil {
+		t.Fatal(err)
+	}
+	if final := bracket.GetMatch("W2-1"); !final.IsPlayable() || final.Slots[1].UserID != "user3" {
+		t.Errorf("the final is %v vs %v, want user1 vs user3", final.Slots[0].UserID, final.Slots[1].UserID)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	ICALENDAR_PRODUCT_ID  = "-//Challenge League//dl//EN"
+	ICALENDAR_UID_DOMAIN  = "challenge-league"
+	ICALENDAR_TIME_LAYOUT = "20060102T150405Z"
+	ICALENDAR_LINE_LENGTH = 75
+	ICALENDAR_LINE_ENDING = "\r\n"
+)
+
+var (
+	discordMentionRegexp  = regexp.MustCompile(`<@!?(\d+)>`)
+	discordMarkdownRegexp = regexp.MustCompile("```" + DISCORD_BLOCK_CODE_TYPE + "|```|\\*\\*|`")
+	discordQuoteRegexp    = regexp.MustCompile(`(?m)^> ?`)
+)
+
+// PrintPlainText strips the discord markdown from the message
+func PrintPlainText(msg string) string {
+	msg = discordMentionRegexp.ReplaceAllString(msg, "@$1")
+	msg = discordMarkdownRegexp.ReplaceAllString(msg, "")
+	msg = discordQuoteRegexp.ReplaceAllString(msg, "")
+	return strings.TrimSpace(msg)
+}
+
+func escapeICalendarText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// foldICalendarLine splits the content line into the lines of at most 75
+// octets as required by RFC 5545 without breaking multi-byte characters
+func foldICalendarLine(line string) string {
+	var folded strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > ICALENDAR_LINE_LENGTH {
+			folded.WriteString(ICALENDAR_LINE_ENDING + " ")
+			length = 1
+		}
+		folded.WriteRune(r)
+		length += size
+	}
+	return folded.String() + ICALENDAR_LINE_ENDING
+}
+
+func formatICalendarTime(t time.Time) string {
+	return t.UTC().Format(ICALENDAR_TIME_LAYOUT)
+}
+
+// GetMatchCalendarDateTimes returns the start and the end of a match, the
+// scheduled matches have not started yet
+func GetMatchCalendarDateTimes(matchState *MatchState) (time.Time, time.Time) {
+	if matchState.Started || matchState.DateTimeScheduled.IsZero() {
+		return matchState.DateTimeStart, matchState.DateTimeEnd
+	}
+	return matchState.DateTimeScheduled, matchState.DateTimeScheduled.Add(matchState.Duration)
+}
+
+// GetMatchCalendarSequence counts the revisions of the event, the history of
+// the match only grows and the start of a scheduled match moves the event
+func GetMatchCalendarSequence(matchState *MatchState) int {
+	sequence := len(matchState.History)
+	if matchState.Started && !matchState.DateTimeScheduled.IsZero() {
+		sequence += 1
+	}
+	return sequence
+}
+
+// GetMatchCalendarDateTimeModified returns the time of the last revision
+// counted by GetMatchCalendarSequence
+func GetMatchCalendarDateTimeModified(matchState *MatchState) time.Time {
+	var dateTimeModified time.Time
+	for _, teamUser := range matchState.ScheduledTeamUsers {
+		if teamUser.DateTimeJoin.After(dateTimeModified) {
+			dateTimeModified = teamUser.DateTimeJoin
+		}
+	}
+	if matchState.Started && matchState.DateTimeStart.After(dateTimeModified) {
+		dateTimeModified = matchState.DateTimeStart
+	}
+	for _, event := range matchState.History {
+		if event.DateTime.After(dateTimeModified) {
+			dateTimeModified = event.DateTime
+		}
+	}
+	return dateTimeModified
+}
+
+// GetMatchOpponentUsernames returns the players of the other teams, the teams
+// of a scheduled match are built once it opens so its other players are used
+func GetMatchOpponentUsernames(userID string, matchState *MatchState) []string {
+	var usernames []string
+	if len(matchState.Teams) == 0 {
+		for _, teamUser := range matchState.ScheduledTeamUsers {
+			if teamUser.User.Nakama.ID != userID {
+				usernames = append(usernames, teamUser.User.Nakama.Username)
+			}
+		}
+		return usernames
+	}
+
+	teamNumber := GetTeamNumberFromUserAndMatch(userID, matchState)
+	for n, team := range matchState.Teams {
+		if n == teamNumber {
+			continue
+		}
+		for _, teamUser := range team.TeamUsers {
+			usernames = append(usernames, teamUser.User.Nakama.Username)
+		}
+	}
+	return usernames
+}
+
+func PrintICalendarEvent(userID string, matchState *MatchState, dateTimeStamp time.Time) string {
+	dateTimeStart, dateTimeEnd := GetMatchCalendarDateTimes(matchState)
+	summary := fmt.Sprintf("%v match", matchState.MatchProfile)
+	if opponents := GetMatchOpponentUsernames(userID, matchState); len(opponents) > 0 {
+		summary += " vs " + strings.Join(opponents, ", ")
+	}
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + fmt.Sprintf("%v@%v", matchState.MatchID, ICALENDAR_UID_DOMAIN),
+		"DTSTAMP:" + formatICalendarTime(dateTimeStamp),
+		"DTSTART:" + formatICalendarTime(dateTimeStart),
+		"DTEND:" + formatICalendarTime(dateTimeEnd),
+		"SEQUENCE:" + strconv.Itoa(GetMatchCalendarSequence(matchState)),
+	}
+	if dateTimeModified := GetMatchCalendarDateTimeModified(matchState); !dateTimeModified.IsZero() {
+		lines = append(lines, "LAST-MODIFIED:"+formatICalendarTime(dateTimeModified))
+	}
+	lines = append(lines,
+		"SUMMARY:"+escapeICalendarText(summary),
+		"DESCRIPTION:"+escapeICalendarText(PrintPlainText(PrintMatchState(matchState))),
+		"CATEGORIES:"+escapeICalendarText(matchState.MatchProfile),
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+	)
+	var msg string
+	for _, line := range lines {
+		msg += foldICalendarLine(line)
+	}
+	return msg
+}
+
+func PrintICalendar(userID string, matchStateList []*MatchState) string {
+	dateTimeStamp := time.Now().UTC()
+	msg := foldICalendarLine("BEGIN:VCALENDAR") +
+		foldICalendarLine("VERSION:2.0") +
+		foldICalendarLine("PRODID:"+ICALENDAR_PRODUCT_ID) +
+		foldICalendarLine("CALSCALE:GREGORIAN") +
+		foldICalendarLine("METHOD:PUBLISH")
+	for _, matchState := range matchStateList {
+		msg += PrintICalendarEvent(userID, matchState, dateTimeStamp)
+	}
+	return msg + foldICalendarLine("END:VCALENDAR")
+}
+
+func getCalendarMatchStateList(cmdBuilder *commandsBuilder, userID string) ([]*MatchState, error) {
+	matchStateList, err := getMatchStateList(cmdBuilder, MATCH_COLLECTION)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var calendarMatchStateList []*MatchState
+	for _, matchState := range matchStateList {
+		if !matchState.Active && matchState.Status != MATCH_STATUS_SCHEDULED {
+			continue
+		}
+		if dateTimeStart, _ := GetMatchCalendarDateTimes(matchState); dateTimeStart.IsZero() {
+			continue
+		}
+		if IsUserIDInScheduledMatch(userID, matchState) {
+			calendarMatchStateList = append(calendarMatchStateList, matchState)
+		}
+	}
+	return calendarMatchStateList, nil
+}
+
+func getCmdCalendarExport(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [user]",
+		Short: "Export the active and scheduled matches of a user as an **iCalendar** file",
+		Long: `Export the active and scheduled matches of a user as an **iCalendar** (.ics) file
+Events keep the same UID on every export and count their revisions in SEQUENCE, so re-importing updates the existing events`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(args) > 0 {
+				if account, err = getAccount(cmdBuilder, args[0]); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			matchStateList, err := getCalendarMatchStateList(cmdBuilder, account.User.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), PrintICalendar(account.User.Id, matchStateList))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func getCmdCalendar(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "calendar",
+		Aliases: []string{"cal"},
+		Short:   "League matches **calendar**",
+		Long:    `League matches **calendar**`,
+	}
+	cmd.AddCommand(getCmdCalendarExport(cmdBuilder))
+	return cmd
+}
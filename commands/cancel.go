@@ -47,6 +47,9 @@ func getCmdCancel(cmdBuilder *commandsBuilder) *cobra.Command {
 				log.Error(err)
 				return err
 			}
+			if matchID, _ := cmd.Flags().GetString("matchID"); matchID != "" {
+				return cancelScheduledMatch(cmdBuilder, cmd, account, matchID)
+			}
 			ticketID, _ := cmd.Flags().GetString("ticketID")
 			var ticketState *TicketState
 
@@ -121,5 +124,6 @@ func getCmdCancel(cmdBuilder *commandsBuilder) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringP("ticketID", "t", "", "usage")
+	cmd.Flags().StringP("matchID", "m", "", "Cancel the scheduled match by the MatchID")
 	return cmd
 }
@@ -275,6 +275,11 @@ func readUserStorageObjects(cmdBuilder *commandsBuilder, collection string, key
 }
 
 func listUserStorageObjects(cmdBuilder *commandsBuilder, collection string, userID string, cursor string) ([]*api.StorageObject, error) {
+	objects, _, err := listUserStorageObjectsWithCursor(cmdBuilder, collection, userID, cursor)
+	return objects, err
+}
+
+func listUserStorageObjectsWithCursor(cmdBuilder *commandsBuilder, collection string, userID string, cursor string) ([]*api.StorageObject, string, error) {
 	storageObjectLists, err := cmdBuilder.nakamaCtx.Client.ListStorageObjects(cmdBuilder.nakamaCtx.Ctx, &api.ListStorageObjectsRequest{
 		Collection: collection,
 		UserId:     userID,
@@ -284,11 +289,11 @@ func listUserStorageObjects(cmdBuilder *commandsBuilder, collection string, user
 
 	if err != nil {
 		log.Error(err)
-		return nil, err
+		return nil, "", err
 	}
 	if len(storageObjectLists.Objects) == 0 {
 		log.Infof("storageObjectList is empty")
-		return nil, nil
+		return nil, storageObjectLists.Cursor, nil
 	}
 
 	f := func(storageObject *api.StorageObject) int64 {
@@ -300,7 +305,26 @@ func listUserStorageObjects(cmdBuilder *commandsBuilder, collection string, user
 		return f(objects[i]) > f(objects[j])
 	})
 
-	return objects, nil
+	return objects, storageObjectLists.Cursor, nil
+}
+
+// listAllUserStorageObjects pages through the collection until the cursor
+// is exhausted
+func listAllUserStorageObjects(cmdBuilder *commandsBuilder, collection string, userID string) ([]*api.StorageObject, error) {
+	var objects []*api.StorageObject
+	cursor := ""
+	for {
+		page, nextCursor, err := listUserStorageObjectsWithCursor(cmdBuilder, collection, userID, cursor)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		objects = append(objects, page...)
+		if nextCursor == "" || nextCursor == cursor {
+			return objects, nil
+		}
+		cursor = nextCursor
+	}
 }
 
 type iterableSlice struct {
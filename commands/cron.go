@@ -0,0 +1,195 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cronField struct {
+	Name  string
+	Min   int
+	Max   int
+	Names []string
+}
+
+var (
+	CRON_FIELDS = []*cronField{
+		&cronField{Name: "minute", Min: 0, Max: 59},
+		&cronField{Name: "hour", Min: 0, Max: 23},
+		&cronField{Name: "day of month", Min: 1, Max: 31},
+		&cronField{Name: "month", Min: 1, Max: 12, Names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+		// sunday is both 0 and 7
+		&cronField{Name: "day of week", Min: 0, Max: 7, Names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+	}
+	CRON_MACROS = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// CRON_MAX_SEARCH_YEARS bounds the search of the next time, e.g. 0 0 30 2 *
+// never comes
+const CRON_MAX_SEARCH_YEARS = 5
+
+// cronSchedule holds the allowed values of every field
+type cronSchedule struct {
+	fields [][]bool
+	// the day matches either the day of month or the day of week when both
+	// are restricted
+	restrictedDays bool
+}
+
+// NormalizeCronExpression accepts the fields separated by the commas as well,
+// e.g. 0,12,*,*,* as the discord arguments are split by the spaces
+func NormalizeCronExpression(expression string) string {
+	fields := strings.Fields(expression)
+	if len(fields) == 1 && strings.Count(expression, ",") == len(CRON_FIELDS)-1 {
+		fields = strings.Split(expression, ",")
+	}
+	return strings.Join(fields, " ")
+}
+
+func (f *cronField) parseValue(value string) (int, error) {
+	for i, name := range f.Names {
+		if strings.EqualFold(value, name) {
+			return f.Min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("'%v' is not a valid %v", value, f.Name)
+	}
+	if n < f.Min || n > f.Max {
+		return 0, fmt.Errorf("%v %v is out of range %v-%v", f.Name, n, f.Min, f.Max)
+	}
+	return n, nil
+}
+
+// parse returns the allowed values of the field indexed by the value
+func (f *cronField) parse(field string) ([]bool, error) {
+	allowed := make([]bool, f.Max+1)
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart := item, ""
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rangePart, stepPart = item[:i], item[i+1:]
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return nil, fmt.Errorf("'%v' is not a valid %v step", stepPart, f.Name)
+			}
+		}
+		start, end := f.Min, f.Max
+		if rangePart != "*" {
+			bounds := strings.Split(rangePart, "-")
+			if len(bounds) > 2 {
+				return nil, fmt.Errorf("'%v' is not a valid %v range", rangePart, f.Name)
+			}
+			var err error
+			if start, err = f.parseValue(bounds[0]); err != nil {
+				return nil, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = f.parseValue(bounds[1]); err != nil {
+					return nil, err
+				}
+				if end < start {
+					return nil, fmt.Errorf("%v range %v is reversed", f.Name, rangePart)
+				}
+			} else if stepPart != "" {
+				return nil, fmt.Errorf("%v step requires a range or *, got '%v'", f.Name, item)
+			}
+		}
+		for value := start; value <= end; value += step {
+			allowed[value] = true
+		}
+	}
+	return allowed, nil
+}
+
+func parseCronExpression(expression string) (*cronSchedule, error) {
+	if macro, ok := CRON_MACROS[expression]; ok {
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != len(CRON_FIELDS) {
+		return nil, fmt.Errorf("Cron expression '%v' must have %v fields: minute hour day-of-month month day-of-week", expression, len(CRON_FIELDS))
+	}
+	schedule := &cronSchedule{
+		restrictedDays: !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*"),
+	}
+	for i, field := range fields {
+		allowed, err := CRON_FIELDS[i].parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("Cron expression '%v' is invalid: %v", expression, err)
+		}
+		schedule.fields = append(schedule.fields, allowed)
+	}
+	// sunday is both 0 and 7
+	schedule.fields[4][0] = schedule.fields[4][0] || schedule.fields[4][7]
+	return schedule, nil
+}
+
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dayOfMonth := s.fields[2][t.Day()]
+	dayOfWeek := s.fields[4][int(t.Weekday())]
+	if s.restrictedDays {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
+
+// ValidateCronExpression checks the standard 5 field cron expression
+// "minute hour day-of-month month day-of-week" used by the reset schedules
+func ValidateCronExpression(expression string) error {
+	_, err := parseCronExpression(expression)
+	return err
+}
+
+// GetCronNextTime returns the first time of the schedule after the time in
+// UTC, the time the leaderboard with the reset schedule is reset next
+func GetCronNextTime(expression string, after time.Time) (time.Time, error) {
+	schedule, err := parseCronExpression(expression)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(CRON_MAX_SEARCH_YEARS, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !schedule.fields[3][int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !schedule.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !schedule.fields[1][t.Hour()]:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !schedule.fields[0][t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Cron expression '%v' does not run in the next %v years", expression, CRON_MAX_SEARCH_YEARS)
+}
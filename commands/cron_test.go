@@ -0,0 +1,144 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCronNextTime(t *testing.T) {
+	after := time.Date(2020, 3, 15, 10, 30, 0, 0, time.UTC)
+	for _, test := range []struct {
+		expression string
+		want       time.Time
+	}{
+		{"* * * * *", time.Date(2020, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2020, 3, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 12 * * *", time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// the 15th of March 2020 is a sunday
+		{"0 9 * * MON-FRI", time.Date(2020, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 3, 22, 0, 0, 0, 0, time.UTC)},
+		// the day of month or the day of week when both are restricted
+		{"0 0 20 * MON", time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2020, 3, 22, 0, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(test.expression, func(t *testing.T) {
+			next, err := GetCronNextTime(test.expression, after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !next.Equal(test.want) {
+				t.Errorf("%v, want %v", next, test.want)
+			}
+		})
+	}
+
+	if next, err := GetCronNextTime("0 0 30 2 *", after); err == nil {
+		t.Errorf("%v, want an error for the day which never comes", next)
+	}
+}
+
+func TestValidateCronExpression(t *testing.T) {
+	for _, test := range []struct {
+		expression string
+		valid      bool
+	}{
+		{"* * * * *", true},
+		{"0 0 * * 1", true},
+		{"0,30 8-18 * * MON-FRI", true},
+		{"*/15 0-12/2 1 jan,jul sun", true},
+		{"0 0 * * 7", true},
+		{"@daily", true},
+		{"@weekly", true},
+		{"", false},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"* * * FOO *", false},
+		{"5-1 * * * *", false},
+		{"1-2-3 * * * *", false},
+		{"*/0 * * * *", false},
+		{"*/x * * * *", false},
+		{"5/10 * * * *", false},
+		{"@every", false},
+	} {
+		t.Run(test.expression, func(t *testing.T) {
+			err := ValidateCronExpression(test.expression)
+			if test.valid && err != nil {
+				t.Errorf("%v", err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("want an error")
+			}
+		})
+	}
+}
+
+func TestNormalizeCronExpression(t *testing.T) {
+	for _, test := range []struct {
+		expression string
+		want       string
+	}{
+		{"0 12 * * *", "0 12 * * *"},
+		{"  0  12 * *   * ", "0 12 * * *"},
+		{"0,12,*,*,*", "0 12 * * *"},
+		// the list of one field is kept
+		{"0,30 * * * *", "0,30 * * * *"},
+		{"0,15,30,45", "0,15,30,45"},
+		{"@daily", "@daily"},
+	} {
+		t.Run(test.expression, func(t *testing.T) {
+			if got := NormalizeCronExpression(test.expression); got != test.want {
+				t.Errorf("'%v', want '%v'", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateSeasonResetSchedule(t *testing.T) {
+	now := time.Date(2020, 3, 15, 10, 30, 0, 0, time.UTC)
+	season := &Season{
+		DateTimeStart: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+		DateTimeEnd:   time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, test := range []struct {
+		resetSchedule string
+		valid         bool
+	}{
+		{"", true},
+		{"0 0 2 */3 *", true},
+		// the reset at the end of the season races the archive
+		{"0 0 1 */3 *", false},
+		{"@daily", false},
+		{"0 0 20 3 *", false},
+	} {
+		season.ResetSchedule = test.resetSchedule
+		if err := validateSeasonResetSchedule(season, now); (err == nil) != test.valid {
+			t.Errorf("%v: error %v, want valid %v", test.resetSchedule, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/challenge-league/nakama-go/context"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	MATCH_DISPUTE_COLLECTION = "dispute_data"
+
+	MATCH_DISPUTE_STATUS_OPEN     = "Open"
+	MATCH_DISPUTE_STATUS_RESOLVED = "Resolved"
+)
+
+// MatchDispute is opened when the teams report conflicting results and
+// stays open until a moderator picks the winner.
+type MatchDispute struct {
+	MatchID          string
+	Status           string
+	TeamResults      []*TeamResult
+	Results          []*MatchResult
+	DateTimeCreated  time.Time
+	DateTimeResolved time.Time
+	ResolvedByUserID string
+	WinnerTeamNumber int
+	Draw             bool
+	Version          string
+}
+
+// MatchDisputeCreateRequest makes the server build the dispute from the match
+// state and create it keyed by the match, only the first of the concurrent
+// reports creates it. The match moves to the disputed status in the same
+// write and the created dispute is returned, nothing is returned when the
+// dispute already exists.
+type MatchDisputeCreateRequest struct {
+	MatchID     string
+	MatchStatus string
+}
+
+type MatchDisputeResolveRequest struct {
+	MatchID          string
+	UserID           string
+	WinnerTeamNumber int
+	Draw             bool
+}
+
+func PrintMatchDispute(matchDispute *MatchDispute) string {
+	return ExecuteTemplate(
+		"> **Match result is disputed!**\n"+
+			"```"+DISCORD_BLOCK_CODE_TYPE+"\n"+
+			`MatchID: {{.MatchID}}
+Status: {{.Status}}
+Created: {{.DateTimeCreated | formatTimeAsDate}}`+"```\n"+
+			PrintTeamResults(matchDispute.TeamResults)+
+			`> Proofs:
+{{range $index, $element := .Results}}>   <@{{.DiscordID}}> {{if not .Draw }}**Team {{.TeamNumber}}** {{if .Win}}**Win**{{else}}**Lose**{{end}}{{else}}**Draw**{{end}} {{if .ProofLink}}{{.ProofLink}}{{else}}no proof{{end}}
+{{end}}{{if eq .Status "`+MATCH_DISPUTE_STATUS_RESOLVED+`"}}> Resolved by <@{{.ResolvedByUserID}}>: {{if .Draw}}**Draw**{{else}}**Team {{.WinnerTeamNumber}} Win**{{end}}
+{{else}}> A moderator will resolve the dispute with: **dl resolve {{.MatchID}} --winner <team>**
+{{end}}`,
+		matchDispute)
+}
+
+func getMatchDispute(cmdBuilder *commandsBuilder, matchID string) (*MatchDispute, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, MATCH_DISPUTE_COLLECTION, matchID, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if len(storageObjects) == 0 {
+		return nil, nil
+	}
+
+	var matchDispute *MatchDispute
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &matchDispute); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	matchDispute.Version = storageObjects[0].Version
+	return matchDispute, nil
+}
+
+// checkMatchResultDispute opens a dispute once the reported results of the
+// teams contradict each other.
+func checkMatchResultDispute(cmdBuilder *commandsBuilder, cmd *cobra.Command, matchID string) error {
+	matchState, err := getMatchState(cmdBuilder, matchID, MATCH_COLLECTION)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if matchState == nil || matchState.Status == MATCH_STATUS_DISPUTED || !IsMatchResultDisputed(matchState) {
+		return nil
+	}
+
+	payload, _ := json.Marshal(&MatchDisputeCreateRequest{
+		MatchID:     matchState.MatchID,
+		MatchStatus: MATCH_STATUS_DISPUTED,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchDisputeCreate", Payload: string(payload)})
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if result.Payload == "" {
+		return nil
+	}
+
+	var matchDispute *MatchDispute
+	if err := json.Unmarshal([]byte(result.Payload), &matchDispute); err != nil {
+		log.Error(err)
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), PrintMatchDispute(matchDispute))
+	return nil
+}
+
+func getCmdMatchDisputeResolve(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resolve [matchID]",
+		Short: "**Resolve** a disputed match result",
+		Long:  `**Resolve** a disputed match result by setting the winner team or a draw`,
+		Args:  matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			matchID := args[0]
+			winner, _ := cmd.Flags().GetString("winner")
+			draw, _ := cmd.Flags().GetBool("draw")
+			if winner == "" && !draw {
+				return fmt.Errorf("Please specify the winner team with --winner or a draw with --draw")
+			}
+			if winner != "" && draw {
+				return fmt.Errorf("Please specify either the winner team with --winner or a draw with --draw, not both")
+			}
+
+			matchDispute, err := getMatchDispute(cmdBuilder, matchID)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchDispute == nil || matchDispute.Status != MATCH_DISPUTE_STATUS_OPEN {
+				return fmt.Errorf("No open dispute found for the match **%v**", matchID)
+			}
+
+			matchState, err := getMatchState(cmdBuilder, matchID, MATCH_COLLECTION)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchState == nil {
+				return fmt.Errorf("No match found for **%v**", matchID)
+			}
+
+			winnerTeamNumber := MATCH_RESULT_TEAM_DRAW
+			if !draw {
+				if winnerTeamNumber, err = strconv.Atoi(winner); err != nil {
+					log.Error(err)
+					return fmt.Errorf("Incorrect team number %v", winner)
+				}
+				if winnerTeamNumber < 0 || winnerTeamNumber > (len(matchState.Teams)-1) {
+					return fmt.Errorf("Incorrect team number %v", winnerTeamNumber)
+				}
+			}
+
+			payload, _ := json.Marshal(&MatchDisputeResolveRequest{
+				MatchID:          matchID,
+				UserID:           account.User.Id,
+				WinnerTeamNumber: winnerTeamNumber,
+				Draw:             draw,
+			})
+			log.Infof("%+v\n", string(payload))
+
+			result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchDisputeResolve", Payload: string(payload)})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if result.Payload != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), result.Payload)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringP("winner", "w", "", "Winner team #")
+	cmd.Flags().BoolP("draw", "d", false, "Resolve the dispute as a draw")
+	return cmd
+}
@@ -0,0 +1,241 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	EXPORT_FORMAT_CSV  = "csv"
+	EXPORT_FORMAT_JSON = "json"
+)
+
+var (
+	EXPORT_FORMATS                = []string{EXPORT_FORMAT_CSV, EXPORT_FORMAT_JSON}
+	LEADERBOARD_EXPORT_CSV_HEADER = []string{"rank", "owner_id", "discord_id", "username", "score", "subscore", "num_score", "update_time"}
+)
+
+type AccountListGetRequest struct {
+	UserIDs []string
+}
+
+type LeaderboardExportRow struct {
+	Rank       int64     `json:"rank"`
+	OwnerID    string    `json:"owner_id"`
+	DiscordID  string    `json:"discord_id"`
+	Username   string    `json:"username"`
+	Score      int64     `json:"score"`
+	Subscore   int64     `json:"subscore"`
+	NumScore   int32     `json:"num_score"`
+	UpdateTime time.Time `json:"update_time"`
+}
+
+func (r *LeaderboardExportRow) CSV() []string {
+	return []string{
+		strconv.FormatInt(r.Rank, 10),
+		r.OwnerID,
+		r.DiscordID,
+		r.Username,
+		strconv.FormatInt(r.Score, 10),
+		strconv.FormatInt(r.Subscore, 10),
+		strconv.FormatInt(int64(r.NumScore), 10),
+		r.UpdateTime.UTC().Format(time.RFC3339),
+	}
+}
+
+func NewLeaderboardExportRows(records []*api.LeaderboardRecord, accounts []*api.Account) []*LeaderboardExportRow {
+	discordIDs := map[string]string{}
+	for _, account := range accounts {
+		discordIDs[account.User.Id] = account.CustomId
+	}
+
+	var rows []*LeaderboardExportRow
+	for _, record := range records {
+		rows = append(rows, &LeaderboardExportRow{
+			Rank:       record.GetRank(),
+			OwnerID:    record.GetOwnerId(),
+			DiscordID:  discordIDs[record.GetOwnerId()],
+			Username:   record.GetUsername().GetValue(),
+			Score:      record.GetScore(),
+			Subscore:   record.GetSubscore(),
+			NumScore:   record.GetNumScore(),
+			UpdateTime: record.GetUpdateTime().AsTime(),
+		})
+	}
+	return rows
+}
+
+func PrintLeaderboardExportCSV(rows []*LeaderboardExportRow) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(LEADERBOARD_EXPORT_CSV_HEADER); err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		if err := writer.Write(row.CSV()); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	return buf.String(), writer.Error()
+}
+
+// PrintLeaderboardExportJSON prints the rows as newline-delimited JSON
+func PrintLeaderboardExportJSON(rows []*LeaderboardExportRow) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+func getAccountList(cmdBuilder *commandsBuilder, userIDs []string) ([]*api.Account, error) {
+	var accounts []*api.Account
+	for start := 0; start < len(userIDs); start += MAX_LIST_LIMIT {
+		end := start + MAX_LIST_LIMIT
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		payload, _ := json.Marshal(&AccountListGetRequest{
+			UserIDs: userIDs[start:end],
+		})
+		log.Infof("%+v\n", string(payload))
+
+		result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "AccountListGet", Payload: string(payload)})
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		var accountList []*api.Account
+		if result.Payload == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(result.Payload), &accountList); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		accounts = append(accounts, accountList...)
+	}
+	return accounts, nil
+}
+
+func getCmdExportLeaderboard(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "leaderboard [leaderboardID]",
+		Short: "Export all records of a leaderboard as **CSV** or **JSON**",
+		Long: `Export all records of a leaderboard as **CSV** or newline-delimited **JSON**
+Every row has the rank, owner ID, Discord ID, username, score, subscore, number of scores and update time`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			leaderboardID := MAIN_LEADERBOARD
+			if len(args) > 0 {
+				leaderboardID = args[0]
+			}
+			format, _ := cmd.Flags().GetString("format")
+			if !IsStringInSlice(format, EXPORT_FORMATS) {
+				return fmt.Errorf("Format %v is invalid. Available formats: %+v", format, EXPORT_FORMATS)
+			}
+
+			request := &api.ListLeaderboardRecordsRequest{
+				LeaderboardId: leaderboardID,
+				Limit:         &wrapperspb.Int32Value{Value: MAX_LIST_LIMIT},
+			}
+			owners, _ := cmd.Flags().GetStringSlice("owners")
+			for _, owner := range owners {
+				account, err := getAccount(cmdBuilder, owner)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				request.OwnerIds = append(request.OwnerIds, account.User.Id)
+			}
+			if expiry, _ := cmd.Flags().GetInt64("expiry"); expiry > 0 {
+				request.Expiry = &wrapperspb.Int64Value{Value: expiry}
+			}
+
+			var records []*api.LeaderboardRecord
+			var err error
+			if len(request.OwnerIds) > 0 {
+				// the owner records are returned apart from the ranked page
+				result, err := cmdBuilder.nakamaCtx.Client.ListLeaderboardRecords(cmdBuilder.nakamaCtx.Ctx, request)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				records = result.GetOwnerRecords()
+			} else {
+				if records, err = getLeaderboardRecordList(cmdBuilder, request); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			var ownerIDs []string
+			for _, record := range records {
+				ownerIDs = append(ownerIDs, record.GetOwnerId())
+			}
+			accounts, err := getAccountList(cmdBuilder, ownerIDs)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			rows := NewLeaderboardExportRows(records, accounts)
+			var export string
+			switch format {
+			case EXPORT_FORMAT_CSV:
+				export, err = PrintLeaderboardExportCSV(rows)
+			case EXPORT_FORMAT_JSON:
+				export, err = PrintLeaderboardExportJSON(rows)
+			}
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), export)
+			return nil
+		},
+	}
+	cmd.Flags().StringP("format", "f", EXPORT_FORMAT_CSV, fmt.Sprintf("Export format: %+v", EXPORT_FORMATS))
+	cmd.Flags().StringSliceP("owners", "", []string{}, "Export only the records of these users")
+	cmd.Flags().Int64P("expiry", "e", 0, "Export the records that expire at this unix time, e.g. of a previous leaderboard reset")
+	return cmd
+}
+
+func getCmdExport(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the league data",
+		Long:  `Export the league data`,
+	}
+	cmd.AddCommand(getCmdExportLeaderboard(cmdBuilder))
+	return cmd
+}
@@ -0,0 +1,237 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// DURATION_EXTENSION_QUORUM_KEY is the config key of the share of the
+	// players of every team required to accept an extension, it is read from
+	// .dataleague or the DURATION_EXTENSION_QUORUM environment variable
+	DURATION_EXTENSION_QUORUM_KEY     = "duration_extension_quorum"
+	DEFAULT_DURATION_EXTENSION_QUORUM = 0.5
+)
+
+// MatchDurationExtension is a proposal to extend the match, it is applied by
+// the server once the Quorum of players of every team has accepted it
+type MatchDurationExtension struct {
+	UserID          string
+	DiscordID       string
+	Hours           int
+	Quorum          float64
+	AcceptedUserIDs []string
+	DateTime        time.Time
+}
+
+// MatchDurationExtensionRequest carries the Quorum of the league when the
+// extension is proposed, the server keeps it in the extension
+type MatchDurationExtensionRequest struct {
+	MatchID string
+	UserID  string
+	Hours   int
+	Quorum  float64
+}
+
+func getDurationExtensionQuorum() (float64, error) {
+	if !viper.IsSet(DURATION_EXTENSION_QUORUM_KEY) {
+		return DEFAULT_DURATION_EXTENSION_QUORUM, nil
+	}
+	quorum := viper.GetFloat64(DURATION_EXTENSION_QUORUM_KEY)
+	if quorum <= 0 || quorum > 1 {
+		return 0, fmt.Errorf("The %v %v is invalid, expected a share of the players greater than 0 and at most 1", DURATION_EXTENSION_QUORUM_KEY, viper.GetString(DURATION_EXTENSION_QUORUM_KEY))
+	}
+	return quorum, nil
+}
+
+// GetDurationExtensionQuorum returns the quorum the extension was proposed
+// with, the extensions proposed before it was configurable use the default
+func GetDurationExtensionQuorum(extension *MatchDurationExtension) float64 {
+	if extension.Quorum == 0 {
+		return DEFAULT_DURATION_EXTENSION_QUORUM
+	}
+	return extension.Quorum
+}
+
+// GetDurationExtensionQuorumSize returns the number of players of the team
+// required to accept the extension, at least one player of every team
+func GetDurationExtensionQuorumSize(team *Team, quorum float64) int {
+	size := int(math.Ceil(float64(len(team.TeamUsers)) * quorum))
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+func GetDurationExtensionAcceptedCount(team *Team, extension *MatchDurationExtension) int {
+	count := 0
+	for _, teamUser := range team.TeamUsers {
+		if IsStringInSlice(teamUser.User.Nakama.ID, extension.AcceptedUserIDs) {
+			count += 1
+		}
+	}
+	return count
+}
+
+func IsDurationExtensionQuorumReached(matchState *MatchState, extension *MatchDurationExtension) bool {
+	for _, team := range matchState.Teams {
+		if GetDurationExtensionAcceptedCount(team, extension) < GetDurationExtensionQuorumSize(team, GetDurationExtensionQuorum(extension)) {
+			return false
+		}
+	}
+	return true
+}
+
+func PrintMatchDurationExtension(matchState *MatchState) string {
+	extension := matchState.PendingDurationExtension
+	if extension == nil {
+		return ""
+	}
+	msg := fmt.Sprintf("> Pending extension by **%v** hours proposed by <@%v>:\n", extension.Hours, extension.DiscordID)
+	for n, team := range matchState.Teams {
+		msg += fmt.Sprintf("> Team **%v** accepted %v/%v:", n, GetDurationExtensionAcceptedCount(team, extension), GetDurationExtensionQuorumSize(team, GetDurationExtensionQuorum(extension)))
+		for _, teamUser := range team.TeamUsers {
+			if IsStringInSlice(teamUser.User.Nakama.ID, extension.AcceptedUserIDs) {
+				msg += fmt.Sprintf(" <@%v>", teamUser.User.Nakama.CustomID)
+			}
+		}
+		msg += "\n"
+	}
+	return msg + "> To accept the extension please type: **dl extend --accept**\n"
+}
+
+func validateDurationExtension(matchState *MatchState, hours int) error {
+	if hours < MIN_MATCH_DURATION_HOURS {
+		return fmt.Errorf("extension can not be less than %v hours", MIN_MATCH_DURATION_HOURS)
+	}
+	if matchState.Duration+time.Duration(hours)*time.Hour > MAX_MATCH_DURATION_HOURS*time.Hour {
+		return fmt.Errorf("duration can not be more than %v hours, the match can be extended by %v at most",
+			MAX_MATCH_DURATION_HOURS, formatDuraiton(MAX_MATCH_DURATION_HOURS*time.Hour-matchState.Duration))
+	}
+	return nil
+}
+
+func getCmdMatchDurationExtend(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extend [hours]",
+		Short: "Propose to **extend** the active match by the number of hours",
+		Long: `Propose to **extend** the active match by the number of hours
+The extension is applied after the quorum of players of every team accepts it with **dl extend --accept**`,
+		Args: matchAll(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			matchState, err := getLastUserMatchState(cmdBuilder, account, MATCH_COLLECTION)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchState == nil {
+				return fmt.Errorf("No match found for <@%v>", account.CustomId)
+			}
+			if !matchState.Active || !matchState.Started {
+				return fmt.Errorf("The match **%v** is not in progress", matchState.MatchID)
+			}
+			if !IsUserIDInMatch(account.User.Id, matchState) {
+				return fmt.Errorf("<@%v> is not a player of the match **%v**", account.CustomId, matchState.MatchID)
+			}
+
+			accept, _ := cmd.Flags().GetBool("accept")
+			cancel, _ := cmd.Flags().GetBool("cancel")
+			extension := matchState.PendingDurationExtension
+
+			request := &MatchDurationExtensionRequest{
+				MatchID: matchState.MatchID,
+				UserID:  account.User.Id,
+			}
+			rpcID := "MatchDurationExtensionPropose"
+			switch {
+			case accept || cancel:
+				if extension == nil {
+					return fmt.Errorf("There is no pending extension in the match **%v**", matchState.MatchID)
+				}
+				if cancel && extension.UserID != account.User.Id {
+					return fmt.Errorf("Only <@%v> can cancel the extension", extension.DiscordID)
+				}
+				if accept && IsStringInSlice(account.User.Id, extension.AcceptedUserIDs) {
+					return fmt.Errorf("<@%v> has already accepted the extension", account.CustomId)
+				}
+				request.Hours = extension.Hours
+				rpcID = "MatchDurationExtensionCancel"
+				if accept {
+					// the duration could have been changed by a moderator since the proposal
+					if err := validateDurationExtension(matchState, extension.Hours); err != nil {
+						return err
+					}
+					rpcID = "MatchDurationExtensionAccept"
+				}
+			default:
+				if extension != nil {
+					return fmt.Errorf("There is already a pending extension in the match **%v**, please accept or cancel it first", matchState.MatchID)
+				}
+				if len(args) == 0 {
+					return fmt.Errorf("Please specify the number of hours to extend the match")
+				}
+				hours, err := strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("'%v' is not a valid number of hours", args[0])
+				}
+				if err := validateDurationExtension(matchState, hours); err != nil {
+					return err
+				}
+				quorum, err := getDurationExtensionQuorum()
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				request.Hours = hours
+				request.Quorum = quorum
+			}
+
+			payload, _ := json.Marshal(request)
+			log.Infof("%+v\n", string(payload))
+
+			result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: rpcID, Payload: string(payload)})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if result.Payload != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), result.Payload)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolP("accept", "a", false, "Accept the pending extension")
+	cmd.Flags().BoolP("cancel", "c", false, "Cancel your pending extension")
+	return cmd
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"time"
+)
+
+const (
+	MATCH_HISTORY_ACTION_PICK           = "pick"
+	MATCH_HISTORY_ACTION_PICK_UNDO      = "pick undo"
+	MATCH_HISTORY_ACTION_TRADE_OFFER    = "trade offer"
+	MATCH_HISTORY_ACTION_TRADE_ACCEPT   = "trade accept"
+	MATCH_HISTORY_ACTION_TRADE_CANCEL   = "trade cancel"
+	MATCH_HISTORY_ACTION_SUBSTITUTE     = "substitute"
+	MATCH_HISTORY_ACTION_EXTEND_PROPOSE = "extend propose"
+	MATCH_HISTORY_ACTION_EXTEND_ACCEPT  = "extend accept"
+	MATCH_HISTORY_ACTION_EXTEND_CANCEL  = "extend cancel"
+)
+
+// MatchHistoryEvent is appended to MatchState.History by the server every
+// time the match line-up or state is changed by a user command.
+type MatchHistoryEvent struct {
+	Action           string
+	UserID           string
+	DiscordID        string
+	TargetUserIDs    []string
+	TargetDiscordIDs []string
+	Reason           string
+	DateTime         time.Time
+}
+
+func PrintMatchHistory(matchState *MatchState) string {
+	return ExecuteTemplate(
+		`{{if .History}}> History:
+{{range $index, $element := .History}}> {{.DateTime | formatTimeAsDate}} <@{{.DiscordID}}> **{{.Action}}**{{range .TargetDiscordIDs}} <@{{.}}>{{end}}{{if .Reason}} ({{.Reason}}){{end}}
+{{end}}`+"\n"+`{{end}}`,
+		matchState)
+}
+
+func GetLastMatchHistoryEvent(matchState *MatchState, actions ...string) *MatchHistoryEvent {
+	for i := len(matchState.History) - 1; i >= 0; i-- {
+		if IsStringInSlice(matchState.History[i].Action, actions) {
+			return matchState.History[i]
+		}
+	}
+	return nil
+}
@@ -54,10 +54,22 @@ func getCmdLeaderboardCreate(cmdBuilder *commandsBuilder) *cobra.Command {
 			sortOrder, _ := cmd.Flags().GetString("sortOrder")
 			operator, _ := cmd.Flags().GetString("operator")
 			resetSchedule, _ := cmd.Flags().GetString("resetSchedule")
+			precision, _ := cmd.Flags().GetInt("precision")
+			lowerIsBetter, _ := cmd.Flags().GetBool("lowerIsBetter")
+			codec, err := NewScoreCodec(precision, lowerIsBetter)
+			if err != nil {
+				return err
+			}
+			if lowerIsBetter {
+				if cmd.Flags().Changed("sortOrder") && sortOrder != "asc" {
+					return fmt.Errorf("--lowerIsBetter sorts the leaderboard in the asc order, it can not be combined with --sortOrder %v", sortOrder)
+				}
+				sortOrder = "asc"
+			}
 			payload, _ := json.Marshal(&LeaderboardCreateRequest{
 				ID:            uuid.Must(uuid.NewV4()).String(),
 				Authoritative: authoritative,
-				Metadata:      map[string]interface{}{},
+				Metadata:      codec.Metadata(),
 				Operator:      operator,
 				ResetSchedule: resetSchedule,
 				SortOrder:     sortOrder,
@@ -78,6 +90,8 @@ func getCmdLeaderboardCreate(cmdBuilder *commandsBuilder) *cobra.Command {
 	cmdLeaderboardCreate.Flags().StringP("sortOrder", "s", "desc", "usage")
 	cmdLeaderboardCreate.Flags().StringP("operator", "o", "best", "usage")
 	cmdLeaderboardCreate.Flags().StringP("resetSchedule", "r", "", "0 12 * * *")
+	cmdLeaderboardCreate.Flags().IntP("precision", "p", DEFAULT_SCORE_PRECISION, "Number of the score digits after the decimal point")
+	cmdLeaderboardCreate.Flags().BoolP("lowerIsBetter", "", false, "Lower scores rank higher, sets the sort order to asc")
 	return cmdLeaderboardCreate
 }
 
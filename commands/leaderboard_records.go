@@ -18,6 +18,7 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/micro/go-micro/v2/logger"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -30,6 +31,12 @@ import (
 
 const (
 	MAIN_LEADERBOARD = "Main Leaderboard"
+
+	LEADERBOARD_AROUND_ME          = "me"
+	DEFAULT_LEADERBOARD_PAGE_LIMIT = 10
+	// LEADERBOARD_MAX_PAGE bounds the cursors followed for --page, the deeper
+	// pages are browsed with --cursor
+	LEADERBOARD_MAX_PAGE = 10
 )
 
 type LeaderboardRecordWriteRequest struct {
@@ -48,10 +55,13 @@ type LeaderboardRecordDeleteRequest struct {
 
 var cmdLeaderboardRecordAliases = []string{"leaderboard"}
 
-func PrintLeaderboardRecords(leaderboardRecords []*api.LeaderboardRecord) string {
-	return fmt.Sprintf("> Match Leaderboard **%v** :\n", leaderboardRecords[0].LeaderboardId) +
-		ExecuteTemplate(`{{range $index, $element := .}}> <@{{.Username.Value}}> {{.Score}}.{{.Subscore}}
-{{end}}`+"\n", leaderboardRecords)
+func PrintLeaderboardRecords(leaderboardRecords []*api.LeaderboardRecord, codec *ScoreCodec) string {
+	first, last := leaderboardRecords[0], leaderboardRecords[len(leaderboardRecords)-1]
+	msg := fmt.Sprintf("> Leaderboard **%v**, ranks %v-%v:\n", first.LeaderboardId, first.Rank, last.Rank)
+	for _, record := range leaderboardRecords {
+		msg += fmt.Sprintf("> **#%v** <@%v> %v\n", record.Rank, record.Username.GetValue(), codec.Decode(record.Score, record.Subscore))
+	}
+	return msg + "\n"
 }
 
 func getCmdLeaderboardRecordAdd(cmdBuilder *commandsBuilder) *cobra.Command {
@@ -203,7 +213,12 @@ func getCmdLeaderboardRecordsGet(cmdBuilder *commandsBuilder) *cobra.Command {
 			}
 
 			if len(result.GetRecords()) > 0 {
-				fmt.Fprintf(cmd.OutOrStdout(), PrintLeaderboardRecords(result.GetRecords()))
+				codec, err := getMatchScoreCodec(cmdBuilder, matchState)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), PrintLeaderboardRecords(result.GetRecords(), codec))
 			} else {
 				fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("No leaderboard records found for match **%v**", matchState.MatchID))
 			}
@@ -224,56 +239,168 @@ func getCmdTopLeaderboardRecordsGet(cmdBuilder *commandsBuilder) *cobra.Command
 		Use:     "top [discordID]",
 		Aliases: cmdTournamentAliases,
 		Short:   "Get the **league leaderboard**",
-		Long:    `Get the **league leaderboard**`,
+		Long: `Get the **league leaderboard**
+Browse the pages with **--page** or **--cursor**, see the ranks around a user with **--around me** or **--around @user**
+Show the leaderboard of a match mode and duration with **--mode 2vs2 --duration long**`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
-			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
-			if err != nil {
-				log.Error(err)
-				return err
+			limit, _ := cmd.Flags().GetInt("limit")
+			if limit < 1 || limit > MAX_LIST_LIMIT {
+				return fmt.Errorf("limit must be between 1 and %v", MAX_LIST_LIMIT)
+			}
+			page, _ := cmd.Flags().GetInt("page")
+			if page < 1 || page > LEADERBOARD_MAX_PAGE {
+				return fmt.Errorf("page must be between 1 and %v, browse further pages with the **--cursor** of the next page", LEADERBOARD_MAX_PAGE)
+			}
+			cursor, _ := cmd.Flags().GetString("cursor")
+			around, _ := cmd.Flags().GetString("around")
+			if discordID, _ := cmd.Flags().GetString("discordID"); discordID != "" {
+				around = discordID
+			}
+			if around == "" && len(args) > 0 {
+				around = args[0]
 			}
-			discordID, _ := cmd.Flags().GetString("discordID")
-			if discordID != "" {
-				payload, _ := json.Marshal(&AccountGetRequest{
-					Identifier: discordID,
-				})
-				log.Infof("%+v\n", string(payload))
 
-				result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "AccountByCustomIDGet", Payload: string(payload)})
-				if err != nil {
-					log.Error(err)
-					return err
+			leaderboardID := MAIN_LEADERBOARD
+			command := fmt.Sprintf("dl top --limit %v", limit)
+			mode, _ := cmd.Flags().GetString("mode")
+			durationBucket, _ := cmd.Flags().GetString("duration")
+			matchType, _ := cmd.Flags().GetString("type")
+			if mode != "" || durationBucket != "" || cmd.Flags().Changed("type") {
+				modes, ok := MATCH_TYPE_MODES[matchType]
+				if !ok {
+					return fmt.Errorf("Match type %v is invalid. Available match types: %+v", matchType, MATCH_TYPES)
+				}
+				if mode == "" {
+					return fmt.Errorf("Please specify the match mode with **--mode**. Available %v match modes: %+v", matchType, modes)
+				}
+				if !IsStringInSlice(mode, modes) {
+					return fmt.Errorf("Match mode %v is invalid for %v. Available match modes: %+v", mode, matchType, modes)
 				}
+				if durationBucket == "" {
+					durationBucket = GetDurationBucket(DEFAULT_MATCH_DURATION_HOURS * time.Hour)
+				}
+				if !IsStringInSlice(durationBucket, DURATION_BUCKET_NAMES) {
+					return fmt.Errorf("Duration %v is invalid. Available durations: %+v", durationBucket, DURATION_BUCKET_NAMES)
+				}
+				leaderboardID = GetLeaderboardID(mode, matchType, durationBucket)
+				command += fmt.Sprintf(" --mode %v --type %v --duration %v", mode, matchType, durationBucket)
+			}
 
-				if err := json.Unmarshal([]byte(result.Payload), &account); err != nil {
+			var result *api.LeaderboardRecordList
+			var err error
+			if around != "" {
+				var account *api.Account
+				if account, err = getLeaderboardAroundAccount(cmdBuilder, around); err != nil {
 					log.Error(err)
 					return err
 				}
+				result, err = cmdBuilder.nakamaCtx.Client.ListLeaderboardRecordsAroundOwner(cmdBuilder.nakamaCtx.Ctx, &api.ListLeaderboardRecordsAroundOwnerRequest{
+					LeaderboardId: leaderboardID,
+					Limit:         &wrapperspb.UInt32Value{Value: uint32(limit)},
+					OwnerId:       account.User.Id,
+				})
+			} else {
+				result, err = listLeaderboardRecordsPage(cmdBuilder, leaderboardID, limit, page, cursor)
 			}
-
-			result, err := cmdBuilder.nakamaCtx.Client.ListLeaderboardRecordsAroundOwner(cmdBuilder.nakamaCtx.Ctx, &api.ListLeaderboardRecordsAroundOwnerRequest{
-				LeaderboardId: MAIN_LEADERBOARD,
-				Limit:         &wrapperspb.UInt32Value{Value: MAX_LIST_LIMIT},
-				OwnerId:       account.User.Id,
-			})
 			if err != nil {
 				log.Error(err)
 				return err
 			}
 
 			if len(result.GetRecords()) > 0 {
-				fmt.Fprintf(cmd.OutOrStdout(), PrintLeaderboardRecords(result.GetRecords()))
+				codec, err := getScoreCodec(cmdBuilder, leaderboardID)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				fmt.Fprint(cmd.OutOrStdout(), PrintLeaderboardRecords(result.GetRecords(), codec)+
+					PrintLeaderboardCursors(result, command))
 			} else {
-				fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("No leaderboard records found for the %v", MAIN_LEADERBOARD))
+				fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("No leaderboard records found for the %v", leaderboardID))
 			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringP("discordID", "d", "", "usage")
+	cmd.Flags().StringP("discordID", "d", "", "Show the ranks around the discord user, same as **--around**")
+	cmd.Flags().StringP("around", "a", "", "Show the ranks around **me** or the discord username#1234, @username or <@discord_user_id>")
+	cmd.Flags().IntP("page", "p", 1, fmt.Sprintf("Page number up to %v, pages are counted from the top", LEADERBOARD_MAX_PAGE))
+	cmd.Flags().StringP("cursor", "c", "", "Cursor of the page to show")
+	cmd.Flags().IntP("limit", "l", DEFAULT_LEADERBOARD_PAGE_LIMIT, fmt.Sprintf("Number of records per page, maximum is %v", MAX_LIST_LIMIT))
+	cmd.Flags().StringP("mode", "m", "", fmt.Sprintf("Leaderboard of the match mode: %+v", MATCH_TYPE_MODES))
+	cmd.Flags().StringP("type", "t", MATCH_TYPE_CAPTAINS_DRAFT, fmt.Sprintf("Leaderboard of the match type: %+v", MATCH_TYPES))
+	cmd.Flags().StringP("duration", "", "", fmt.Sprintf("Leaderboard of the match duration: %+v, the default is %v", DURATION_BUCKET_NAMES, GetDurationBucket(DEFAULT_MATCH_DURATION_HOURS*time.Hour)))
 	return cmd
 }
 
+func getLeaderboardAroundAccount(cmdBuilder *commandsBuilder, around string) (*api.Account, error) {
+	if around == LEADERBOARD_AROUND_ME {
+		return cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+	}
+	return getAccount(cmdBuilder, around)
+}
+
+// listLeaderboardRecordsPage follows the cursors from the top up to the page
+// unless the cursor of the page is known, a page past the last one is an error
+func listLeaderboardRecordsPage(cmdBuilder *commandsBuilder, leaderboardID string, limit int, page int, cursor string) (*api.LeaderboardRecordList, error) {
+	if cursor != "" {
+		page = 1
+	}
+	for n := 1; ; n++ {
+		result, err := cmdBuilder.nakamaCtx.Client.ListLeaderboardRecords(cmdBuilder.nakamaCtx.Ctx, &api.ListLeaderboardRecordsRequest{
+			LeaderboardId: leaderboardID,
+			Limit:         &wrapperspb.Int32Value{Value: int32(limit)},
+			Cursor:        cursor,
+		})
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		if n == page {
+			return result, nil
+		}
+		if result.GetNextCursor() == "" {
+			return nil, fmt.Errorf("The leaderboard %v has only %v pages of %v records", leaderboardID, n, limit)
+		}
+		cursor = result.GetNextCursor()
+	}
+}
+
+// getLeaderboardRecordList pages through all the records of the leaderboard
+func getLeaderboardRecordList(cmdBuilder *commandsBuilder, request *api.ListLeaderboardRecordsRequest) ([]*api.LeaderboardRecord, error) {
+	var leaderboardRecords []*api.LeaderboardRecord
+	for {
+		result, err := cmdBuilder.nakamaCtx.Client.ListLeaderboardRecords(cmdBuilder.nakamaCtx.Ctx, request)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		leaderboardRecords = append(leaderboardRecords, result.GetRecords()...)
+		if result.GetNextCursor() == "" || len(result.GetRecords()) == 0 {
+			return leaderboardRecords, nil
+		}
+		request.Cursor = result.GetNextCursor()
+	}
+}
+
+// recordListCursors is implemented by the leaderboard and tournament record lists
+type recordListCursors interface {
+	GetPrevCursor() string
+	GetNextCursor() string
+}
+
+func PrintLeaderboardCursors(result recordListCursors, command string) string {
+	msg := ""
+	if result.GetPrevCursor() != "" {
+		msg += fmt.Sprintf("> Previous page: **%v --cursor %v**\n", command, result.GetPrevCursor())
+	}
+	if result.GetNextCursor() != "" {
+		msg += fmt.Sprintf("> Next page: **%v --cursor %v**\n", command, result.GetNextCursor())
+	}
+	return msg
+}
+
 /*
 func getCmdLeaderboardRecordAroundOwnerGet(cmdBuilder *commandsBuilder) *cobra.Command {
 	cmdLeaderboardRecordGet := &cobra.Command{
@@ -0,0 +1,160 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+)
+
+const (
+	DURATION_BUCKET_SHORT  = "short"
+	DURATION_BUCKET_MEDIUM = "medium"
+	DURATION_BUCKET_LONG   = "long"
+
+	// the match results are recorded on MAIN_LEADERBOARD and on the
+	// leaderboard the match is routed to
+	MATCH_EXTENSION_ROUTED_LEADERBOARD_ID = "routed_leaderboard_id"
+	// the routed leaderboards of the match maker ticket by the match mode,
+	// the server routes the match by the mode the ticket is matched in
+	TICKET_EXTENSION_ROUTED_LEADERBOARD_IDS = "routed_leaderboard_ids"
+
+	ROUTED_LEADERBOARD_PREFIX = "leaderboard"
+)
+
+type DurationBucket struct {
+	Name        string
+	MaxDuration time.Duration
+}
+
+// LeaderboardRoutingRule routes the matches to a leaderboard, the empty
+// fields match any value and the empty LeaderboardID names the leaderboard
+// after the mode, the type and the duration bucket of the match. The
+// leaderboard IDs must not contain spaces.
+type LeaderboardRoutingRule struct {
+	MatchProfile   string
+	MatchType      string
+	DurationBucket string
+	LeaderboardID  string
+}
+
+var (
+	DURATION_BUCKETS = []*DurationBucket{
+		&DurationBucket{Name: DURATION_BUCKET_SHORT, MaxDuration: DEFAULT_MATCH_DURATION_HOURS * time.Hour},
+		&DurationBucket{Name: DURATION_BUCKET_MEDIUM, MaxDuration: 12 * time.Hour},
+		&DurationBucket{Name: DURATION_BUCKET_LONG, MaxDuration: MAX_MATCH_DURATION_HOURS * time.Hour},
+	}
+	DURATION_BUCKET_NAMES = []string{DURATION_BUCKET_SHORT, DURATION_BUCKET_MEDIUM, DURATION_BUCKET_LONG}
+
+	// MATCH_TYPE_MODES are the modes the matches of every type are played in
+	MATCH_TYPE_MODES = map[string][]string{
+		MATCH_TYPE_CAPTAINS_DRAFT: CAPTAIN_DRAFT_MODES,
+		MATCH_TYPE_MATCH_MAKER:    MATCH_MAKER_MODES,
+	}
+	MATCH_TYPES = []string{MATCH_TYPE_CAPTAINS_DRAFT, MATCH_TYPE_MATCH_MAKER}
+
+	// the first matching rule wins
+	LEADERBOARD_ROUTING_RULES = []*LeaderboardRoutingRule{
+		&LeaderboardRoutingRule{},
+	}
+
+	createdLeaderboardIDs sync.Map
+)
+
+func GetDurationBucket(duration time.Duration) string {
+	for _, bucket := range DURATION_BUCKETS {
+		if duration <= bucket.MaxDuration {
+			return bucket.Name
+		}
+	}
+	return DURATION_BUCKET_LONG
+}
+
+func (r *LeaderboardRoutingRule) Match(matchProfile string, matchType string, durationBucket string) bool {
+	return (r.MatchProfile == "" || r.MatchProfile == matchProfile) &&
+		(r.MatchType == "" || r.MatchType == matchType) &&
+		(r.DurationBucket == "" || r.DurationBucket == durationBucket)
+}
+
+func GetLeaderboardID(matchProfile string, matchType string, durationBucket string) string {
+	for _, rule := range LEADERBOARD_ROUTING_RULES {
+		if !rule.Match(matchProfile, matchType, durationBucket) {
+			continue
+		}
+		if rule.LeaderboardID != "" {
+			return rule.LeaderboardID
+		}
+		return strings.ToLower(strings.Join([]string{ROUTED_LEADERBOARD_PREFIX, matchProfile, matchType, durationBucket}, "_"))
+	}
+	return MAIN_LEADERBOARD
+}
+
+func GetMatchLeaderboardID(matchProfile string, matchType string, duration time.Duration) string {
+	return GetLeaderboardID(matchProfile, matchType, GetDurationBucket(duration))
+}
+
+// ensureMatchMakerLeaderboards creates the leaderboards of every mode of the
+// match maker ticket and returns them by the mode
+func ensureMatchMakerLeaderboards(cmdBuilder *commandsBuilder, matchModes []string, duration time.Duration) (map[string]string, error) {
+	leaderboardIDs := make(map[string]string)
+	for _, matchMode := range matchModes {
+		leaderboardID := GetMatchLeaderboardID(matchMode, MATCH_TYPE_MATCH_MAKER, duration)
+		if err := ensureLeaderboard(cmdBuilder, leaderboardID); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		leaderboardIDs[matchMode] = leaderboardID
+	}
+	return leaderboardIDs, nil
+}
+
+// ensureLeaderboard creates the leaderboard on the first use, the creation
+// of an existing leaderboard is a no-op on the server. The routed leaderboards
+// share the score codec of MAIN_LEADERBOARD since the same results are
+// recorded on both.
+func ensureLeaderboard(cmdBuilder *commandsBuilder, leaderboardID string) error {
+	if _, ok := createdLeaderboardIDs.Load(leaderboardID); ok {
+		return nil
+	}
+	if leaderboardID == MAIN_LEADERBOARD {
+		return nil
+	}
+	codec, err := getScoreCodec(cmdBuilder, MAIN_LEADERBOARD)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	payload, _ := json.Marshal(&LeaderboardCreateRequest{
+		ID:            leaderboardID,
+		Authoritative: true,
+		SortOrder:     "desc",
+		Operator:      "incr",
+		Metadata:      codec.Metadata(),
+	})
+	log.Infof("%+v\n", string(payload))
+
+	if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "LeaderboardCreate", Payload: string(payload)}); err != nil {
+		log.Error(err)
+		return err
+	}
+	createdLeaderboardIDs.Store(leaderboardID, true)
+	return nil
+}
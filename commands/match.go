@@ -49,6 +49,7 @@ const (
 	MATCH_TYPE_CAPTAINS_DRAFT = "captains_draft"
 
 	MATCH_STATUS_CREATED                     = "Created"
+	MATCH_STATUS_SCHEDULED                   = "Scheduled"
 	MATCH_STATUS_CAPTAINS_DRAFT_IN_PROGRESS  = "Players draft in progress"
 	MATCH_STATUS_AWAITNG_USERS_READY         = "Awaiting users ready"
 	MATCH_STATUS_IN_PROGRESS                 = "In progress"
@@ -56,6 +57,7 @@ const (
 	MATCH_STATUS_ENDED_AFTER_TIME_EXPIRED    = "The match ended after the time expired"
 	MATCH_STATUS_COMPLETED_AHEAD_OF_SCHEDULE = "The match was completed ahead of schedule"
 	MATCH_STATUS_CANCELED                    = "Canceled"
+	MATCH_STATUS_DISPUTED                    = "Disputed"
 
 	SEARCH_MIN_DURATION = "minDuration"
 	SEARCH_MAX_DURATION = "maxDuration"
@@ -65,6 +67,8 @@ const (
 	DEFAULT_MATCH_DURATION_HOURS = 3
 	MIN_MATCH_DURATION_HOURS     = 1
 	MAX_MATCH_DURATION_HOURS     = 48
+
+	POOL_PICK_UNDO_GRACE_PERIOD = 60 * time.Second
 )
 
 var (
@@ -97,32 +101,45 @@ type DiscordMessage struct {
 }
 
 type MatchState struct {
-	Active                 bool
-	CancelUserIDs          []string
-	CaptainUserIDs         []string
-	CaptainTurnUserID      string
-	DateTimeStart          time.Time
-	DateTimeEnd            time.Time
-	Duration               time.Duration
-	ActualDateTimeEnd      time.Time
-	ActualDuration         time.Duration
-	Debug                  bool
-	Started                bool
-	Status                 string
-	Teams                  []*Team
-	MatchID                string
-	MatchProfile           string
-	MatchType              string
-	PoolUserIDs            []string
-	PoolUserCustomIDs      []string
-	Results                []*MatchResult
-	ReadyUserIDs           []string
-	StorageUserID          string
-	StorageCollection      string
-	Version                string
-	DiscordChannels        []*DiscordChannel
-	DiscordNewMatchMessage DiscordMessage
-	MaxNumScore            int
+	Active                   bool
+	CancelUserIDs            []string
+	CaptainUserIDs           []string
+	CaptainTurnUserID        string
+	DateTimeScheduled        time.Time
+	ScheduleReminders        []time.Duration
+	ScheduledTeamUsers       []*TeamUser
+	ReadyDeadline            time.Duration
+	DateTimeReadyDeadline    time.Time
+	DateTimeStart            time.Time
+	DateTimeEnd              time.Time
+	Duration                 time.Duration
+	ActualDateTimeEnd        time.Time
+	ActualDuration           time.Duration
+	Debug                    bool
+	Started                  bool
+	Status                   string
+	Teams                    []*Team
+	MatchID                  string
+	MatchProfile             string
+	MatchType                string
+	PoolUserIDs              []string
+	PoolUserCustomIDs        []string
+	Results                  []*MatchResult
+	ReadyUserIDs             []string
+	StorageUserID            string
+	StorageCollection        string
+	Version                  string
+	DiscordChannels          []*DiscordChannel
+	DiscordNewMatchMessage   DiscordMessage
+	MaxNumScore              int
+	RoutedLeaderboardID      string
+	TournamentID             string
+	ScoreCodec               *ScoreCodec
+	SubmitWindow             *SubmissionWindow
+	ResultWindow             *SubmissionWindow
+	History                  []*MatchHistoryEvent
+	PendingTrade             *TeamTrade
+	PendingDurationExtension *MatchDurationExtension
 }
 
 type MatchCreateRequest struct {
@@ -146,6 +163,12 @@ type MatchPoolPickRequest struct {
 	UserID        string
 }
 
+type MatchPoolPickUndoRequest struct {
+	MatchID       string
+	CaptainUserID string
+	UserID        string
+}
+
 type MatchStateGetRequest struct {
 	ID                string
 	StorageCollection string
@@ -170,18 +193,23 @@ func PrintMatchState(matchState *MatchState) string {
 	return ExecuteTemplate(
 		"```"+DISCORD_BLOCK_CODE_TYPE+"\n"+`MatchID: {{.MatchID}}`+"```\n"+
 			PrintTeams(matchState.Teams)+
+			PrintSubstitutedUsers(matchState.Teams)+
 			`> Active: {{if .Active}}**True**{{else}}**False**{{end}} 
-> Mode: **{{.MatchProfile}}**
+> Mode: **{{.MatchProfile}}**{{ if .TournamentID }}
+> Tournament: **{{.TournamentID}}**{{end}}
 > Status: **{{.Status}}**
-> Duration: **{{ .Duration | formatDuration }}**{{ if .Started }}
+> Duration: **{{ .Duration | formatDuration }}**{{ if .DateTimeScheduled | dateIsNotZero }}
+> Scheduled date: **{{ .DateTimeScheduled | formatTimeAsDate }}**{{end}}{{ if .Started }}
 > Start date: **{{ .DateTimeStart | formatTimeAsDate }}**
 > End date: **{{ .DateTimeEnd | formatTimeAsDate }}**
 {{ if .Active }}> Elapsed time: **{{ .DateTimeStart | getDurationSinceDate | formatDuration }}**{{end}}{{ if .ActualDateTimeEnd | dateIsNotZero }}
 > Actual end date: **{{ .ActualDateTimeEnd | formatTimeAsDate }}**{{end}}{{ if .ActualDuration }}
 > Actual duration: **{{ .ActualDuration | formatDuration }}**{{end}}{{end}}`+"\n"+
 			PrintDraftPool(matchState)+
+			PrintMatchDurationExtension(matchState)+
 			PrintMatchResults(matchState)+
-			PrintMatchReadyUserIDs(matchState),
+			PrintMatchReadyUserIDs(matchState)+
+			PrintMatchHistory(matchState),
 		matchState)
 }
 
@@ -197,6 +225,7 @@ func PrintDraftPool(matchState *MatchState) string {
 {{if .PoolUserCustomIDs}}> Draft Pool User IDs: 
 {{range $index, $element := .PoolUserCustomIDs}}> <@{{.}}>
 {{end}}{{end}}`+"\n"+
+			PrintTeamTrade(matchState.PendingTrade)+
 			`{{end}}`,
 		matchState)
 }
@@ -449,7 +478,8 @@ func getCmdPickUserFromMatchPool(cmdBuilder *commandsBuilder) *cobra.Command {
 		Use:     "pick ",
 		Aliases: []string{"p"},
 		Short:   "Pick user from the captains draft pool by the UserID",
-		Long:    `Pick user from the captains draft pool by the UserID`,
+		Long: `Pick user from the captains draft pool by the UserID
+Use **dl pick --undo** to return your last pick to the draft pool within ` + formatDuraiton(POOL_PICK_UNDO_GRACE_PERIOD),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
 
@@ -461,13 +491,26 @@ func getCmdPickUserFromMatchPool(cmdBuilder *commandsBuilder) *cobra.Command {
 
 			captainUserID := account.User.Id
 			ticketState, err := getLastUserTicketState(cmdBuilder, account)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if ticketState == nil {
+				return fmt.Errorf("No tickets found for <@%v>", account.CustomId)
+			}
 			matchID := ticketState.MatchID
 
+			undo, _ := cmd.Flags().GetBool("undo")
+			if undo {
+				return poolPickUndo(cmdBuilder, cmd, account, matchID)
+			}
+
 			userID, _ := cmd.Flags().GetString("userID")
 			if userID == "" && len(args) > 0 {
 				userID = args[0]
 				log.Infof("%+s", userID)
-			} else {
+			}
+			if userID == "" {
 				return fmt.Errorf("Please specify the UserID to pick from the draft pool")
 			}
 			account, err = getAccount(cmdBuilder, userID)
@@ -496,9 +539,55 @@ func getCmdPickUserFromMatchPool(cmdBuilder *commandsBuilder) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringP("userID", "u", "", "usage")
+	cmd.Flags().Bool("undo", false, fmt.Sprintf("Undo your last pick within %v", formatDuraiton(POOL_PICK_UNDO_GRACE_PERIOD)))
 	return cmd
 }
 
+func poolPickUndo(cmdBuilder *commandsBuilder, cmd *cobra.Command, account *api.Account, matchID string) error {
+	matchState, err := getMatchState(cmdBuilder, matchID, MATCH_COLLECTION)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if matchState == nil {
+		return fmt.Errorf("No match found for <@%v>", account.CustomId)
+	}
+	if matchState.Started {
+		return fmt.Errorf("The match **%v** has already started, picks can not be undone", matchState.MatchID)
+	}
+
+	lastPick := GetLastMatchHistoryEvent(matchState, MATCH_HISTORY_ACTION_PICK, MATCH_HISTORY_ACTION_PICK_UNDO)
+	if lastPick == nil || lastPick.Action != MATCH_HISTORY_ACTION_PICK {
+		return fmt.Errorf("There is no pick to undo in the match **%v**", matchState.MatchID)
+	}
+	if lastPick.UserID != account.User.Id {
+		return fmt.Errorf("Only <@%v> can undo the last pick", lastPick.DiscordID)
+	}
+	if time.Now().UTC().Sub(lastPick.DateTime) > POOL_PICK_UNDO_GRACE_PERIOD {
+		return fmt.Errorf("The last pick can only be undone within %v", formatDuraiton(POOL_PICK_UNDO_GRACE_PERIOD))
+	}
+	if len(lastPick.TargetUserIDs) == 0 {
+		return fmt.Errorf("The last pick has no picked user")
+	}
+
+	payload, _ := json.Marshal(MatchPoolPickUndoRequest{
+		MatchID:       matchState.MatchID,
+		CaptainUserID: account.User.Id,
+		UserID:        lastPick.TargetUserIDs[0],
+	})
+	log.Infof("%+v\n", string(payload))
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "PoolPickUndo", Payload: string(payload)})
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if result.Payload != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), result.Payload)
+	}
+	return nil
+}
+
 func getCmdAddUserToMatchPool(cmdBuilder *commandsBuilder) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "add ",
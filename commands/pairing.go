@@ -0,0 +1,794 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/challenge-league/nakama-go/context"
+	"github.com/gofrs/uuid"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	PAIRING_COLLECTION = "pairing_data"
+
+	PAIRING_FORMAT_SWISS       = "swiss"
+	PAIRING_FORMAT_ROUND_ROBIN = "round_robin"
+
+	TIE_BREAKER_BUCHHOLZ         = "buchholz"
+	TIE_BREAKER_SONNEBORN_BERGER = "sonneborn_berger"
+	TIE_BREAKER_WINS             = "wins"
+
+	PAIRING_BYE = -1
+
+	PAIRING_POINTS_WIN  = 1.0
+	PAIRING_POINTS_DRAW = 0.5
+
+	SWISS_PAIRING_MAX_STEPS = 100000
+)
+
+var (
+	PAIRING_FORMATS = []string{PAIRING_FORMAT_SWISS, PAIRING_FORMAT_ROUND_ROBIN}
+	TIE_BREAKERS    = []string{TIE_BREAKER_BUCHHOLZ, TIE_BREAKER_SONNEBORN_BERGER, TIE_BREAKER_WINS}
+
+	DEFAULT_TIE_BREAKERS = map[string][]string{
+		PAIRING_FORMAT_SWISS:       []string{TIE_BREAKER_BUCHHOLZ, TIE_BREAKER_SONNEBORN_BERGER},
+		PAIRING_FORMAT_ROUND_ROBIN: []string{TIE_BREAKER_SONNEBORN_BERGER, TIE_BREAKER_WINS},
+	}
+)
+
+// PairingMatch refers to the teams by their IDs, the away team of a bye is
+// PAIRING_BYE
+type PairingMatch struct {
+	HomeTeamID   int
+	AwayTeamID   int
+	MatchID      string
+	Decided      bool
+	Draw         bool
+	WinnerTeamID int
+}
+
+type PairingRound struct {
+	Number  int
+	Matches []*PairingMatch
+}
+
+type PairingTournament struct {
+	TournamentID   string
+	Title          string
+	Format         string
+	MatchProfile   string
+	Duration       int
+	RoundCount     int
+	TieBreakers    []string
+	Teams          []*Team
+	Rounds         []*PairingRound
+	DateTimeCreate time.Time
+}
+
+type PairingStanding struct {
+	Team            *Team
+	Rank            int
+	Points          float64
+	Wins            int
+	Draws           int
+	Losses          int
+	Byes            int
+	TieBreaks       []float64
+	OpponentTeamIDs []int
+}
+
+// PairingUpdateRequest writes the pairings if nobody has changed them since
+// they were read, the version * creates new pairings
+type PairingUpdateRequest struct {
+	PairingTournament *PairingTournament
+	Version           string
+}
+
+// NewParticipantTeams splits the seeded participants into the teams of the
+// match profile, the seeds are dealt in the snake order to balance the teams
+// and the best seed of every team is its captain
+func NewParticipantTeams(participants []*BracketParticipant, matchProfile string) ([]*Team, error) {
+	mode, ok := CAPTAINS_DRAFT_MODES_MAP[matchProfile]
+	if !ok {
+		return nil, fmt.Errorf("Match mode %v is invalid. Available match modes: %+v", matchProfile, CAPTAIN_DRAFT_MODES)
+	}
+	if len(participants)%mode.UsersInTeam != 0 {
+		return nil, fmt.Errorf("%v participants can not be split into the %v teams of %v players", len(participants), matchProfile, mode.UsersInTeam)
+	}
+
+	teams := make([]*Team, len(participants)/mode.UsersInTeam)
+	for i, participant := range participants {
+		position := i % len(teams)
+		if (i/len(teams))%2 == 1 {
+			position = len(teams) - 1 - position
+		}
+		if teams[position] == nil {
+			teams[position] = &Team{
+				ID:   position,
+				Name: strings.Split(participant.Username, "#")[0],
+			}
+		}
+		teams[position].TeamUsers = append(teams[position].TeamUsers, &TeamUser{
+			User: &User{
+				Nakama: &NakamaUser{
+					CustomID: participant.DiscordID,
+					ID:       participant.UserID,
+					Username: participant.Username,
+				},
+			},
+			Captain: len(teams[position].TeamUsers) == 0,
+		})
+	}
+	return teams, nil
+}
+
+// GetPairingTeamNumber returns the number of the team in the match, any
+// player of the team identifies it since the others may have been substituted
+func GetPairingTeamNumber(team *Team, matchState *MatchState) int {
+	for _, teamUser := range team.TeamUsers {
+		if teamNumber := GetTeamNumberFromUserAndMatch(teamUser.User.Nakama.ID, matchState); teamNumber != -1 {
+			return teamNumber
+		}
+	}
+	return -1
+}
+
+func newPairingBye(teamID int) *PairingMatch {
+	return &PairingMatch{
+		HomeTeamID:   teamID,
+		AwayTeamID:   PAIRING_BYE,
+		Decided:      true,
+		WinnerTeamID: teamID,
+	}
+}
+
+func (m *PairingMatch) IsBye() bool {
+	return m.AwayTeamID == PAIRING_BYE
+}
+
+func (m *PairingMatch) Decide(winnerTeamNumber int) {
+	m.Decided = true
+	switch winnerTeamNumber {
+	case MATCH_RESULT_TEAM_DRAW:
+		m.Draw = true
+	case 0:
+		m.WinnerTeamID = m.HomeTeamID
+	default:
+		m.WinnerTeamID = m.AwayTeamID
+	}
+}
+
+func (r *PairingRound) IsDecided() bool {
+	for _, match := range r.Matches {
+		if !match.Decided {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRoundRobinRounds schedules every team against every other team with the
+// circle method: the first team is fixed and the others rotate around it
+func GetRoundRobinRounds(teams []*Team) []*PairingRound {
+	var circle []int
+	for _, team := range teams {
+		circle = append(circle, team.ID)
+	}
+	if len(circle)%2 == 1 {
+		circle = append(circle, PAIRING_BYE)
+	}
+
+	var rounds []*PairingRound
+	n := len(circle)
+	for number := 1; number < n; number++ {
+		round := &PairingRound{Number: number}
+		for i := 0; i < n/2; i++ {
+			home, away := circle[i], circle[n-1-i]
+			// alternate the fixed team between home and away
+			if i == 0 && number%2 == 0 {
+				home, away = away, home
+			}
+			switch {
+			case home == PAIRING_BYE:
+				round.Matches = append(round.Matches, newPairingBye(away))
+			case away == PAIRING_BYE:
+				round.Matches = append(round.Matches, newPairingBye(home))
+			default:
+				round.Matches = append(round.Matches, &PairingMatch{HomeTeamID: home, AwayTeamID: away})
+			}
+		}
+		rounds = append(rounds, round)
+		circle = append([]int{circle[0], circle[n-1]}, circle[1:n-1]...)
+	}
+	return rounds
+}
+
+func getPairingKey(a int, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// pairSwissTeams pairs the teams in the standings order with the closest
+// team they have not played yet, backtracking when the rest can not be paired
+// until the steps run out
+func pairSwissTeams(teamIDs []int, played map[[2]int]bool, steps *int) [][2]int {
+	if len(teamIDs) == 0 {
+		return [][2]int{}
+	}
+	for i := 1; i < len(teamIDs) && *steps > 0; i++ {
+		*steps -= 1
+		if played[getPairingKey(teamIDs[0], teamIDs[i])] {
+			continue
+		}
+		var rest []int
+		rest = append(rest, teamIDs[1:i]...)
+		rest = append(rest, teamIDs[i+1:]...)
+		if pairs := pairSwissTeams(rest, played, steps); pairs != nil {
+			return append([][2]int{{teamIDs[0], teamIDs[i]}}, pairs...)
+		}
+	}
+	return nil
+}
+
+func getSwissByeCandidates(standings []*PairingStanding) []int {
+	if len(standings)%2 == 0 {
+		return []int{PAIRING_BYE}
+	}
+	var byeCandidates []int
+	for _, withoutBye := range []bool{true, false} {
+		for i := len(standings) - 1; i >= 0; i-- {
+			if (standings[i].Byes == 0) == withoutBye {
+				byeCandidates = append(byeCandidates, standings[i].Team.ID)
+			}
+		}
+	}
+	return byeCandidates
+}
+
+func newSwissRound(number int, standings []*PairingStanding, byeTeamID int, played map[[2]int]bool, steps *int) *PairingRound {
+	var teamIDs []int
+	for _, standing := range standings {
+		if standing.Team.ID != byeTeamID {
+			teamIDs = append(teamIDs, standing.Team.ID)
+		}
+	}
+	pairs := pairSwissTeams(teamIDs, played, steps)
+	if pairs == nil {
+		return nil
+	}
+	round := &PairingRound{Number: number}
+	for _, pair := range pairs {
+		round.Matches = append(round.Matches, &PairingMatch{HomeTeamID: pair[0], AwayTeamID: pair[1]})
+	}
+	if byeTeamID != PAIRING_BYE {
+		round.Matches = append(round.Matches, newPairingBye(byeTeamID))
+	}
+	return round
+}
+
+// newGreedySwissRound pairs every team with the closest team it has not
+// played yet or with the closest one if it has played all of them
+func newGreedySwissRound(number int, standings []*PairingStanding, byeTeamID int, played map[[2]int]bool) *PairingRound {
+	var teamIDs []int
+	for _, standing := range standings {
+		if standing.Team.ID != byeTeamID {
+			teamIDs = append(teamIDs, standing.Team.ID)
+		}
+	}
+	round := &PairingRound{Number: number}
+	for len(teamIDs) > 1 {
+		opponent := 1
+		for i := 1; i < len(teamIDs); i++ {
+			if !played[getPairingKey(teamIDs[0], teamIDs[i])] {
+				opponent = i
+				break
+			}
+		}
+		round.Matches = append(round.Matches, &PairingMatch{HomeTeamID: teamIDs[0], AwayTeamID: teamIDs[opponent]})
+		teamIDs = append(teamIDs[1:opponent], teamIDs[opponent+1:]...)
+	}
+	if byeTeamID != PAIRING_BYE {
+		round.Matches = append(round.Matches, newPairingBye(byeTeamID))
+	}
+	return round
+}
+
+// PairSwissRound pairs the next round by the current standings, the lowest
+// ranked team without a bye gets the bye and the rematches are avoided
+// unless no other pairing is found
+func PairSwissRound(t *PairingTournament) *PairingRound {
+	standings := GetPairingStandings(t)
+	played := map[[2]int]bool{}
+	for _, round := range t.Rounds {
+		for _, match := range round.Matches {
+			if !match.IsBye() {
+				played[getPairingKey(match.HomeTeamID, match.AwayTeamID)] = true
+			}
+		}
+	}
+
+	byeCandidates := getSwissByeCandidates(standings)
+	steps := SWISS_PAIRING_MAX_STEPS
+	for _, byeTeamID := range byeCandidates {
+		if round := newSwissRound(len(t.Rounds)+1, standings, byeTeamID, played, &steps); round != nil {
+			return round
+		}
+	}
+	return newGreedySwissRound(len(t.Rounds)+1, standings, byeCandidates[0], played)
+}
+
+// GetSwissRoundCount is the number of rounds to find a single unbeaten team
+func GetSwissRoundCount(teamCount int) int {
+	rounds := int(math.Ceil(math.Log2(float64(teamCount))))
+	if rounds < 1 {
+		rounds = 1
+	}
+	return rounds
+}
+
+func NewPairingTournament(tournamentID string, title string, format string, matchProfile string, teams []*Team, roundCount int, tieBreakers []string, duration int) (*PairingTournament, error) {
+	if !IsStringInSlice(format, PAIRING_FORMATS) {
+		return nil, fmt.Errorf("Format %v is invalid. Available formats: %+v", format, PAIRING_FORMATS)
+	}
+	mode, ok := CAPTAINS_DRAFT_MODES_MAP[matchProfile]
+	if !ok {
+		return nil, fmt.Errorf("Match mode %v is invalid. Available match modes: %+v", matchProfile, CAPTAIN_DRAFT_MODES)
+	}
+	if len(teams) < 2 {
+		return nil, fmt.Errorf("The %v tournament needs at least 2 teams, %v registered", format, len(teams))
+	}
+	for _, team := range teams {
+		if len(team.TeamUsers) != mode.UsersInTeam {
+			return nil, fmt.Errorf("The team %v has %v players, the %v teams have %v", team.Name, len(team.TeamUsers), matchProfile, mode.UsersInTeam)
+		}
+	}
+	if len(tieBreakers) == 0 {
+		tieBreakers = DEFAULT_TIE_BREAKERS[format]
+	}
+	for _, tieBreaker := range tieBreakers {
+		if !IsStringInSlice(tieBreaker, TIE_BREAKERS) {
+			return nil, fmt.Errorf("Tie-breaker %v is invalid. Available tie-breakers: %+v", tieBreaker, TIE_BREAKERS)
+		}
+	}
+
+	t := &PairingTournament{
+		TournamentID:   tournamentID,
+		Title:          title,
+		Format:         format,
+		MatchProfile:   matchProfile,
+		Duration:       duration,
+		TieBreakers:    tieBreakers,
+		Teams:          teams,
+		DateTimeCreate: time.Now().UTC(),
+	}
+	switch format {
+	case PAIRING_FORMAT_ROUND_ROBIN:
+		t.Rounds = GetRoundRobinRounds(teams)
+		t.RoundCount = len(t.Rounds)
+	case PAIRING_FORMAT_SWISS:
+		t.RoundCount = roundCount
+		if t.RoundCount == 0 {
+			t.RoundCount = GetSwissRoundCount(len(teams))
+		}
+		if t.RoundCount < 1 || t.RoundCount >= len(teams)+len(teams)%2 {
+			return nil, fmt.Errorf("The swiss tournament of %v teams can have 1 to %v rounds", len(teams), len(teams)+len(teams)%2-1)
+		}
+		t.Rounds = append(t.Rounds, PairSwissRound(t))
+	}
+	return t, nil
+}
+
+// GetCurrentRound returns the first round with the matches to be played
+func (t *PairingTournament) GetCurrentRound() *PairingRound {
+	for _, round := range t.Rounds {
+		if !round.IsDecided() {
+			return round
+		}
+	}
+	return nil
+}
+
+func (t *PairingTournament) IsFinished() bool {
+	return len(t.Rounds) == t.RoundCount && t.GetCurrentRound() == nil
+}
+
+func (t *PairingTournament) GetTeam(teamID int) *Team {
+	for _, team := range t.Teams {
+		if team.ID == teamID {
+			return team
+		}
+	}
+	return nil
+}
+
+func getPairingTieBreak(tieBreaker string, standing *PairingStanding, standings map[int]*PairingStanding, t *PairingTournament) float64 {
+	switch tieBreaker {
+	case TIE_BREAKER_BUCHHOLZ:
+		buchholz := 0.0
+		for _, opponentTeamID := range standing.OpponentTeamIDs {
+			buchholz += standings[opponentTeamID].Points
+		}
+		return buchholz
+	case TIE_BREAKER_SONNEBORN_BERGER:
+		sonnebornBerger := 0.0
+		for _, round := range t.Rounds {
+			for _, match := range round.Matches {
+				if !match.Decided || match.IsBye() || (match.HomeTeamID != standing.Team.ID && match.AwayTeamID != standing.Team.ID) {
+					continue
+				}
+				opponentTeamID := match.HomeTeamID
+				if opponentTeamID == standing.Team.ID {
+					opponentTeamID = match.AwayTeamID
+				}
+				switch {
+				case match.Draw:
+					sonnebornBerger += PAIRING_POINTS_DRAW * standings[opponentTeamID].Points
+				case match.WinnerTeamID == standing.Team.ID:
+					sonnebornBerger += standings[opponentTeamID].Points
+				}
+			}
+		}
+		return sonnebornBerger
+	case TIE_BREAKER_WINS:
+		return float64(standing.Wins)
+	}
+	return 0
+}
+
+// GetPairingStandings ranks the teams by the points and then by the
+// configured tie-breakers, the seed breaks the remaining ties
+func GetPairingStandings(t *PairingTournament) []*PairingStanding {
+	standingsByTeamID := map[int]*PairingStanding{}
+	var standings []*PairingStanding
+	for _, team := range t.Teams {
+		standing := &PairingStanding{Team: team}
+		standingsByTeamID[team.ID] = standing
+		standings = append(standings, standing)
+	}
+
+	for _, round := range t.Rounds {
+		for _, match := range round.Matches {
+			if !match.Decided {
+				continue
+			}
+			home := standingsByTeamID[match.HomeTeamID]
+			if match.IsBye() {
+				home.Byes += 1
+				home.Points += PAIRING_POINTS_WIN
+				continue
+			}
+			away := standingsByTeamID[match.AwayTeamID]
+			home.OpponentTeamIDs = append(home.OpponentTeamIDs, away.Team.ID)
+			away.OpponentTeamIDs = append(away.OpponentTeamIDs, home.Team.ID)
+			switch {
+			case match.Draw:
+				home.Draws, away.Draws = home.Draws+1, away.Draws+1
+				home.Points, away.Points = home.Points+PAIRING_POINTS_DRAW, away.Points+PAIRING_POINTS_DRAW
+			case match.WinnerTeamID == home.Team.ID:
+				home.Wins, away.Losses = home.Wins+1, away.Losses+1
+				home.Points += PAIRING_POINTS_WIN
+			default:
+				away.Wins, home.Losses = away.Wins+1, home.Losses+1
+				away.Points += PAIRING_POINTS_WIN
+			}
+		}
+	}
+
+	for _, standing := range standings {
+		for _, tieBreaker := range t.TieBreakers {
+			standing.TieBreaks = append(standing.TieBreaks, getPairingTieBreak(tieBreaker, standing, standingsByTeamID, t))
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		for k := range t.TieBreakers {
+			if standings[i].TieBreaks[k] != standings[j].TieBreaks[k] {
+				return standings[i].TieBreaks[k] > standings[j].TieBreaks[k]
+			}
+		}
+		return standings[i].Team.ID < standings[j].Team.ID
+	})
+	for i, standing := range standings {
+		standing.Rank = i + 1
+	}
+	return standings
+}
+
+func PrintPairingTeam(team *Team) string {
+	var users []string
+	for _, teamUser := range team.TeamUsers {
+		users = append(users, fmt.Sprintf("<@%v>", teamUser.User.Nakama.CustomID))
+	}
+	return strings.Join(users, " ")
+}
+
+func PrintPairingStandings(t *PairingTournament, standings []*PairingStanding) string {
+	msg := "> Standings:\n"
+	for _, standing := range standings {
+		msg += fmt.Sprintf("> **#%v** %v **%v** pts (%v-%v-%v)", standing.Rank, PrintPairingTeam(standing.Team), standing.Points, standing.Wins, standing.Draws, standing.Losses)
+		for k, tieBreaker := range t.TieBreakers {
+			msg += fmt.Sprintf(" %v %v", tieBreaker, standing.TieBreaks[k])
+		}
+		msg += "\n"
+	}
+	return msg
+}
+
+func PrintPairingRound(t *PairingTournament, round *PairingRound) string {
+	msg := fmt.Sprintf("> **Round %v**\n", round.Number)
+	for _, match := range round.Matches {
+		home := PrintPairingTeam(t.GetTeam(match.HomeTeamID))
+		if match.IsBye() {
+			msg += fmt.Sprintf("> %v bye\n", home)
+			continue
+		}
+		msg += fmt.Sprintf("> %v vs %v", home, PrintPairingTeam(t.GetTeam(match.AwayTeamID)))
+		switch {
+		case match.Decided && match.Draw:
+			msg += " **draw**"
+		case match.Decided:
+			msg += fmt.Sprintf(" won by **%v**", PrintPairingTeam(t.GetTeam(match.WinnerTeamID)))
+		case match.MatchID != "":
+			msg += fmt.Sprintf(" match **%v**", match.MatchID)
+		default:
+			msg += " waiting for the players"
+		}
+		msg += "\n"
+	}
+	return msg
+}
+
+func PrintPairingTournament(t *PairingTournament) string {
+	msg := fmt.Sprintf("> **%v** %v %v, %v rounds, %v teams\n", t.Title, t.MatchProfile, strings.ReplaceAll(t.Format, "_", " "), t.RoundCount, len(t.Teams))
+	msg += PrintPairingStandings(t, GetPairingStandings(t))
+	if round := t.GetCurrentRound(); round != nil {
+		msg += PrintPairingRound(t, round)
+	} else if t.IsFinished() {
+		msg += "> The tournament is finished\n"
+	}
+	return msg
+}
+
+func getPairingTournament(cmdBuilder *commandsBuilder, tournamentID string) (*PairingTournament, string, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, PAIRING_COLLECTION, tournamentID, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	if len(storageObjects) == 0 {
+		return nil, "", nil
+	}
+	var pairingTournament *PairingTournament
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &pairingTournament); err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	return pairingTournament, storageObjects[0].Version, nil
+}
+
+// updatePairingTournament writes the pairings if nobody has changed them
+// since they were read
+func updatePairingTournament(cmdBuilder *commandsBuilder, pairingTournament *PairingTournament, version string) error {
+	payload, _ := json.Marshal(&PairingUpdateRequest{
+		PairingTournament: pairingTournament,
+		Version:           version,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "PairingUpdate", Payload: string(payload)}); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// syncPairingTournament records the results of the finalised matches, pairs
+// the next swiss round and creates the matches of the current round. The new
+// matches are claimed by the versioned write before they are created as the
+// bracket ones are
+func syncPairingTournament(cmdBuilder *commandsBuilder, t *PairingTournament, version string, changed bool) error {
+	var pendingMatches []*PairingMatch
+	for round := t.GetCurrentRound(); round != nil; round = t.GetCurrentRound() {
+		for _, match := range round.Matches {
+			if match.Decided || match.MatchID == "" {
+				continue
+			}
+			matchState, err := getMatchState(cmdBuilder, match.MatchID, MATCH_ARCHIVE_COLLECTION)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchState == nil {
+				if matchState, err = getMatchState(cmdBuilder, match.MatchID, MATCH_COLLECTION); err != nil {
+					log.Error(err)
+					return err
+				}
+				if matchState == nil {
+					pendingMatches = append(pendingMatches, match)
+				}
+				continue
+			}
+			if matchState.Status == MATCH_STATUS_CANCELED {
+				match.MatchID = ""
+				changed = true
+				continue
+			}
+			winner := GetMatchWinnerTeamNumber(matchState)
+			if winner == MATCH_RESULT_TEAM_UNKNOWN {
+				continue
+			}
+			if winner != MATCH_RESULT_TEAM_DRAW {
+				if GetPairingTeamNumber(t.GetTeam(match.HomeTeamID), matchState) == winner {
+					winner = 0
+				} else {
+					winner = 1
+				}
+			}
+			match.Decide(winner)
+			changed = true
+		}
+		if !round.IsDecided() {
+			break
+		}
+		if t.Format == PAIRING_FORMAT_SWISS && len(t.Rounds) < t.RoundCount {
+			t.Rounds = append(t.Rounds, PairSwissRound(t))
+			changed = true
+		}
+	}
+
+	if round := t.GetCurrentRound(); round != nil {
+		for _, match := range round.Matches {
+			if match.Decided || match.MatchID != "" {
+				continue
+			}
+			match.MatchID = uuid.Must(uuid.NewV4()).String()
+			pendingMatches = append(pendingMatches, match)
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := updatePairingTournament(cmdBuilder, t, version); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	for _, match := range pendingMatches {
+		teams := CloneTeams([]*Team{t.GetTeam(match.HomeTeamID), t.GetTeam(match.AwayTeamID)})
+		for i, team := range teams {
+			team.ID = i
+		}
+		if _, err := createTournamentMatch(cmdBuilder, match.MatchID, t.TournamentID, t.MatchProfile, t.Duration, teams); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func getCmdPairingCreate(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create [tournament]",
+		Short: "Create the **swiss** or **round robin** pairings of the tournament",
+		Long: `Create the **swiss** or **round robin** pairings of the tournament
+Swiss rounds are paired by the standings without rematches, round robin plays every team against every other one
+The participants are split into balanced teams of the match mode`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			duration, _ := cmd.Flags().GetInt("duration")
+			if duration < MIN_MATCH_DURATION_HOURS || duration > MAX_MATCH_DURATION_HOURS {
+				return fmt.Errorf("duration must be between %v and %v hours", MIN_MATCH_DURATION_HOURS, MAX_MATCH_DURATION_HOURS)
+			}
+			format, _ := cmd.Flags().GetString("format")
+			matchProfile, _ := cmd.Flags().GetString("mode")
+			rounds, _ := cmd.Flags().GetInt("rounds")
+			tieBreakers, _ := cmd.Flags().GetStringSlice("tieBreakers")
+
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if pairingTournament, _, err := getPairingTournament(cmdBuilder, tournament.Id); err != nil {
+				log.Error(err)
+				return err
+			} else if pairingTournament != nil {
+				return fmt.Errorf("The tournament **%v** already has the pairings", tournament.Title)
+			}
+
+			userIDs, err := getTournamentParticipantIDs(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			participants, err := getBracketParticipants(cmdBuilder, userIDs)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			SeedParticipants(participants)
+			teams, err := NewParticipantTeams(participants, matchProfile)
+			if err != nil {
+				return err
+			}
+			pairingTournament, err := NewPairingTournament(tournament.Id, tournament.Title, format, matchProfile, teams, rounds, tieBreakers, duration)
+			if err != nil {
+				return err
+			}
+			if err := syncPairingTournament(cmdBuilder, pairingTournament, "*", true); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintPairingTournament(pairingTournament))
+			return nil
+		},
+	}
+	cmd.Flags().StringP("format", "f", PAIRING_FORMAT_SWISS, fmt.Sprintf("Tournament format: %+v", PAIRING_FORMATS))
+	cmd.Flags().StringP("mode", "m", MATCH_PROFILE_1_VS_1, fmt.Sprintf("Match mode, the participants are split into balanced teams: %+v", CAPTAIN_DRAFT_MODES))
+	cmd.Flags().IntP("rounds", "r", 0, "Number of the swiss rounds, enough to find a single unbeaten participant by default")
+	cmd.Flags().StringSliceP("tieBreakers", "t", []string{}, fmt.Sprintf("Tie-breakers in the order of priority: %+v", TIE_BREAKERS))
+	cmd.Flags().IntP("duration", "d", DEFAULT_MATCH_DURATION_HOURS, "Duration of the matches in hours")
+	return cmd
+}
+
+func getCmdPairing(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "pairings [tournament]",
+		Aliases: []string{"standings"},
+		Short:   "Show the **standings** and the current round of the tournament",
+		Long: `Show the **standings** and the current round of the swiss or round robin tournament
+The results of the finished matches are recorded and the next round is created automatically`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			pairingTournament, version, err := getPairingTournament(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if pairingTournament == nil {
+				return fmt.Errorf("The tournament **%v** has no pairings yet", tournament.Title)
+			}
+			if err := syncPairingTournament(cmdBuilder, pairingTournament, version, false); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintPairingTournament(pairingTournament))
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,290 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func newTestPairingTeams(count int) []*Team {
+	participants := newTestBracketParticipants(count)
+	SeedParticipants(participants)
+	teams, _ := NewParticipantTeams(participants, MATCH_PROFILE_1_VS_1)
+	return teams
+}
+
+func TestGetRoundRobinRounds(t *testing.T) {
+	for count := 2; count <= 7; count++ {
+		t.Run(fmt.Sprintf("%v teams", count), func(t *testing.T) {
+			rounds := GetRoundRobinRounds(newTestPairingTeams(count))
+			if want := count + count%2 - 1; len(rounds) != want {
+				t.Errorf("%v rounds, want %v", len(rounds), want)
+			}
+			played := map[[2]int]int{}
+			byes := map[int]int{}
+			for _, round := range rounds {
+				seen := map[int]bool{}
+				for _, match := range round.Matches {
+					for _, teamID := range []int{match.HomeTeamID, match.AwayTeamID} {
+						if teamID != PAIRING_BYE && seen[teamID] {
+							t.Errorf("the team %v plays twice in the round %v", teamID, round.Number)
+						}
+						seen[teamID] = true
+					}
+					if match.IsBye() {
+						byes[match.HomeTeamID] += 1
+						continue
+					}
+					played[getPairingKey(match.HomeTeamID, match.AwayTeamID)] += 1
+				}
+				if len(seen) != count+count%2 {
+					t.Errorf("the round %v has %v teams, want %v", round.Number, len(seen), count)
+				}
+			}
+			for a := 0; a < count; a++ {
+				for b := a + 1; b < count; b++ {
+					if played[[2]int{a, b}] != 1 {
+						t.Errorf("the teams %v and %v play %v times, want once", a, b, played[[2]int{a, b}])
+					}
+				}
+				if want := count % 2; byes[a] != want {
+					t.Errorf("the team %v has %v byes, want %v", a, byes[a], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPairingStandingsTieBreakers(t *testing.T) {
+	// 0 beats 1 and 2, 1 beats 3, 2 and 3 draw
+	newTournament := func(tieBreakers []string) *PairingTournament {
+		return &PairingTournament{
+			Format:      PAIRING_FORMAT_SWISS,
+			TieBreakers: tieBreakers,
+			Teams:       newTestPairingTeams(4),
+			Rounds: []*PairingRound{
+				&PairingRound{Number: 1, Matches: []*PairingMatch{
+					&PairingMatch{HomeTeamID: 0, AwayTeamID: 1, Decided: true, WinnerTeamID: 0},
+					&PairingMatch{HomeTeamID: 2, AwayTeamID: 3, Decided: true, Draw: true},
+				}},
+				&PairingRound{Number: 2, Matches: []*PairingMatch{
+					&PairingMatch{HomeTeamID: 0, AwayTeamID: 2, Decided: true, WinnerTeamID: 0},
+					&PairingMatch{HomeTeamID: 3, AwayTeamID: 1, Decided: true, WinnerTeamID: 1},
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		tieBreakers []string
+		order       []int
+		points      []float64
+		tieBreaks   [][]float64
+	}{
+		{
+			tieBreakers: []string{TIE_BREAKER_BUCHHOLZ},
+			order:       []int{0, 1, 2, 3},
+			points:      []float64{2, 1, 0.5, 0.5},
+			tieBreaks:   [][]float64{{1.5}, {2.5}, {2.5}, {1.5}},
+		},
+		{
+			tieBreakers: []string{TIE_BREAKER_SONNEBORN_BERGER},
+			order:       []int{0, 1, 2, 3},
+			points:      []float64{2, 1, 0.5, 0.5},
+			tieBreaks:   [][]float64{{1.5}, {0.5}, {0.25}, {0.25}},
+		},
+		{
+			tieBreakers: []string{TIE_BREAKER_WINS, TIE_BREAKER_BUCHHOLZ, TIE_BREAKER_SONNEBORN_BERGER},
+			order:       []int{0, 1, 2, 3},
+			points:      []float64{2, 1, 0.5, 0.5},
+			tieBreaks:   [][]float64{{2, 1.5, 1.5}, {1, 2.5, 0.5}, {0, 2.5, 0.25}, {0, 1.5, 0.25}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v", test.tieBreakers), func(t *testing.T) {
+			standings := GetPairingStandings(newTournament(test.tieBreakers))
+			var order []int
+			var points []float64
+			var tieBreaks [][]float64
+			for i, standing := range standings {
+				if standing.Rank != i+1 {
+					t.Errorf("the team %v has the rank %v, want %v", standing.Team.ID, standing.Rank, i+1)
+				}
+				order = append(order, standing.Team.ID)
+				points = append(points, standing.Points)
+				tieBreaks = append(tieBreaks, standing.TieBreaks)
+			}
+			if !reflect.DeepEqual(order, test.order) {
+				t.Errorf("order = %v, want %v", order, test.order)
+			}
+			if !reflect.DeepEqual(points, test.points) {
+				t.Errorf("points = %v, want %v", points, test.points)
+			}
+			if !reflect.DeepEqual(tieBreaks, test.tieBreaks) {
+				t.Errorf("tie-breaks = %v, want %v", tieBreaks, test.tieBreaks)
+			}
+		})
+	}
+}
+
+func TestGetPairingStandingsBuchholzBreaksTie(t *testing.T) {
+	// 1 and 3 have one win each, 3 met the stronger opponents
+	tournament := &PairingTournament{
+		TieBreakers: []string{TIE_BREAKER_BUCHHOLZ},
+		Teams:       newTestPairingTeams(4),
+		Rounds: []*PairingRound{
+			&PairingRound{Number: 1, Matches: []*PairingMatch{
+				&PairingMatch{HomeTeamID: 0, AwayTeamID: 2, Decided: true, WinnerTeamID: 0},
+				&PairingMatch{HomeTeamID: 1, AwayTeamID: 3, Decided: true, WinnerTeamID: 3},
+			}},
+			&PairingRound{Number: 2, Matches: []*PairingMatch{
+				&PairingMatch{HomeTeamID: 0, AwayTeamID: 3, Decided: true, WinnerTeamID: 0},
+				&PairingMatch{HomeTeamID: 2, AwayTeamID: 1, Decided: true, WinnerTeamID: 1},
+			}},
+		},
+	}
+	standings := GetPairingStandings(tournament)
+	var order []int
+	for _, standing := range standings {
+		order = append(order, standing.Team.ID)
+	}
+	// 1 met 3 (1 point) and 2 (0), 3 met 1 (1) and 0 (2)
+	if want := []int{0, 3, 1, 2}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestNewParticipantTeams(t *testing.T) {
+	participants := newTestBracketParticipants(6)
+	SeedParticipants(participants)
+	tests := []struct {
+		matchProfile string
+		count        int
+		teams        [][]string
+	}{
+		{MATCH_PROFILE_1_VS_1, 2, [][]string{{"user1"}, {"user2"}}},
+		{MATCH_PROFILE_2_VS_2, 4, [][]string{{"user1", "user4"}, {"user2", "user3"}}},
+		{MATCH_PROFILE_3_VS_3, 6, [][]string{{"user1", "user4", "user5"}, {"user2", "user3", "user6"}}},
+		{MATCH_PROFILE_2_VS_2, 6, [][]string{{"user1", "user6"}, {"user2", "user5"}, {"user3", "user4"}}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v %v", test.matchProfile, test.count), func(t *testing.T) {
+			teams, err := NewParticipantTeams(participants[:test.count], test.matchProfile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var userIDs [][]string
+			for i, team := range teams {
+				if team.ID != i {
+					t.Errorf("the team %v has the ID %v", i, team.ID)
+				}
+				var teamUserIDs []string
+				for j, teamUser := range team.TeamUsers {
+					if teamUser.Captain != (j == 0) {
+						t.Errorf("%v is captain %v", teamUser.User.Nakama.ID, teamUser.Captain)
+					}
+					teamUserIDs = append(teamUserIDs, teamUser.User.Nakama.ID)
+				}
+				userIDs = append(userIDs, teamUserIDs)
+			}
+			if !reflect.DeepEqual(userIDs, test.teams) {
+				t.Errorf("teams = %v, want %v", userIDs, test.teams)
+			}
+		})
+	}
+
+	if _, err := NewParticipantTeams(participants[:3], MATCH_PROFILE_2_VS_2); err == nil {
+		t.Error("3 participants are split into 2vs2 teams")
+	}
+	if _, err := NewParticipantTeams(participants, "6vs6"); err == nil {
+		t.Error("the participants are split for an unknown mode")
+	}
+}
+
+func TestNewPairingTournamentTeamSize(t *testing.T) {
+	teams := newTestPairingTeams(4)
+	if _, err := NewPairingTournament("tournament", "Cup", PAIRING_FORMAT_ROUND_ROBIN, MATCH_PROFILE_2_VS_2, teams, 0, nil, DEFAULT_MATCH_DURATION_HOURS); err == nil {
+		t.Error("single player teams are accepted for 2vs2")
+	}
+	tournament, err := NewPairingTournament("tournament", "Cup", PAIRING_FORMAT_ROUND_ROBIN, MATCH_PROFILE_1_VS_1, teams, 0, nil, DEFAULT_MATCH_DURATION_HOURS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tournament.MatchProfile != MATCH_PROFILE_1_VS_1 || tournament.RoundCount != 3 {
+		t.Errorf("the tournament is %v with %v rounds, want %v with 3", tournament.MatchProfile, tournament.RoundCount, MATCH_PROFILE_1_VS_1)
+	}
+}
+
+func TestPairSwissRoundAvoidsRematches(t *testing.T) {
+	for _, count := range []int{4, 5, 6, 8} {
+		t.Run(fmt.Sprintf("%v teams", count), func(t *testing.T) {
+			rounds := count + count%2 - 1
+			tournament, err := NewPairingTournament("tournament", "Cup", PAIRING_FORMAT_SWISS, MATCH_PROFILE_1_VS_1, newTestPairingTeams(count), rounds, nil, DEFAULT_MATCH_DURATION_HOURS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			played := map[[2]int]bool{}
+			for {
+				round := tournament.GetCurrentRound()
+				if round == nil {
+					break
+				}
+				for _, match := range round.Matches {
+					if match.IsBye() {
+						continue
+					}
+					key := getPairingKey(match.HomeTeamID, match.AwayTeamID)
+					if played[key] {
+						t.Errorf("the teams %v meet again in the round %v", key, round.Number)
+					}
+					played[key] = true
+					// the better seed wins
+					if match.HomeTeamID < match.AwayTeamID {
+						match.Decide(0)
+					} else {
+						match.Decide(1)
+					}
+				}
+				if len(tournament.Rounds) < tournament.RoundCount {
+					tournament.Rounds = append(tournament.Rounds, PairSwissRound(tournament))
+				}
+			}
+			if !tournament.IsFinished() {
+				t.Errorf("%v of %v rounds are played", len(tournament.Rounds), tournament.RoundCount)
+			}
+		})
+	}
+}
+
+func TestGetPairingTeamNumber(t *testing.T) {
+	participants := newTestBracketParticipants(4)
+	SeedParticipants(participants)
+	teams, err := NewParticipantTeams(participants, MATCH_PROFILE_2_VS_2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchTeams := CloneTeams([]*Team{teams[1], teams[0]})
+	// the captain of the first team has been substituted
+	matchTeams[1].TeamUsers = matchTeams[1].TeamUsers[1:]
+	matchState := &MatchState{Teams: matchTeams}
+	if teamNumber := GetPairingTeamNumber(teams[0], matchState); teamNumber != 1 {
+		t.Errorf("GetPairingTeamNumber() = %v, want 1", teamNumber)
+	}
+	if teamNumber := GetPairingTeamNumber(teams[1], matchState); teamNumber != 0 {
+		t.Errorf("GetPairingTeamNumber() = %v, want 0", teamNumber)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+)
+
+const (
+	PENALTY_COLLECTION     = "penalty_data"
+	PENALTY_REASON_NO_SHOW = "no-show"
+
+	DEFAULT_READY_DEADLINE_MINUTES = 15
+	MIN_READY_DEADLINE_MINUTES     = 5
+	MAX_READY_DEADLINE_MINUTES     = 24 * 60
+
+	// MATCH_EXTENSION_READY_DEADLINE and TICKET_EXTENSION_READY_DEADLINE carry
+	// the time the players have to get ready. The server schedules the expiry
+	// when the match moves to awaiting users ready, a matchmaker match takes
+	// the shortest deadline of its tickets. At the deadline the server awards
+	// the match to the only fully ready team or cancels it, and penalises the
+	// not ready users with the no-show cooldown.
+	MATCH_EXTENSION_READY_DEADLINE  = "ready_deadline"
+	TICKET_EXTENSION_READY_DEADLINE = "ready_deadline"
+)
+
+// UserPenalty is written to PENALTY_COLLECTION of the penalised user, the
+// user can not queue for a new match until DateTimeCooldownEnd
+type UserPenalty struct {
+	UserID              string
+	DiscordID           string
+	MatchID             string
+	Reason              string
+	DateTime            time.Time
+	DateTimeCooldownEnd time.Time
+}
+
+func IsReadyDeadlineExpired(matchState *MatchState, now time.Time) bool {
+	deadline := matchState.DateTimeReadyDeadline
+	return matchState.Status == MATCH_STATUS_AWAITNG_USERS_READY && !deadline.IsZero() && now.After(deadline)
+}
+
+func PrintReadyDeadline(matchState *MatchState) string {
+	deadline := matchState.DateTimeReadyDeadline
+	if deadline.IsZero() {
+		return ""
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return fmt.Sprintf("> Ready deadline **%v** has passed, not ready users forfeit the match\n", formatTimeAsDate(deadline))
+	}
+	return fmt.Sprintf("> Ready deadline: **%v** (**%v** left)\n", formatTimeAsDate(deadline), formatDuraiton(remaining.Round(time.Second)))
+}
+
+func PrintUserPenalties(userPenalties []*UserPenalty) string {
+	return ExecuteTemplate(
+		`{{if .}}> Penalties:
+{{range .}}>   {{.DateTime | formatTimeAsDate}} **{{.Reason}}** `+"`{{.MatchID}}`"+` cooldown until {{.DateTimeCooldownEnd | formatTimeAsDate}}
+{{end}}{{end}}`,
+		userPenalties)
+}
+
+func getUserPenaltyList(cmdBuilder *commandsBuilder, account *api.Account) ([]*UserPenalty, error) {
+	objects, err := listUserStorageObjects(cmdBuilder, PENALTY_COLLECTION, account.User.Id, "")
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var userPenalties []*UserPenalty
+	for _, object := range objects {
+		var userPenalty *UserPenalty
+		if err := json.Unmarshal([]byte(object.Value), &userPenalty); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		userPenalties = append(userPenalties, userPenalty)
+	}
+	return userPenalties, nil
+}
+
+func GetActiveCooldown(userPenalties []*UserPenalty, now time.Time) *UserPenalty {
+	var activePenalty *UserPenalty
+	for _, userPenalty := range userPenalties {
+		if userPenalty.DateTimeCooldownEnd.After(now) &&
+			(activePenalty == nil || userPenalty.DateTimeCooldownEnd.After(activePenalty.DateTimeCooldownEnd)) {
+			activePenalty = userPenalty
+		}
+	}
+	return activePenalty
+}
+
+// checkUserCooldown refuses queueing for the users barred after a no-show
+func checkUserCooldown(cmdBuilder *commandsBuilder, account *api.Account) error {
+	userPenalties, err := getUserPenaltyList(cmdBuilder, account)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if userPenalty := GetActiveCooldown(userPenalties, time.Now().UTC()); userPenalty != nil {
+		return fmt.Errorf("<@%v> can not queue until **%v** after the **%v** in the match **%v**",
+			account.CustomId, formatTimeAsDate(userPenalty.DateTimeCooldownEnd), userPenalty.Reason, userPenalty.MatchID)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/micro/go-micro/v2/logger"
+)
+
+const (
+	PROOF_STATUS_VERIFIED     = "verified"
+	PROOF_STATUS_MISMATCHED   = "mismatched"
+	PROOF_STATUS_UNVERIFIABLE = "unverifiable"
+
+	PROOF_FETCH_TIMEOUT  = 10 * time.Second
+	PROOF_MAX_BODY_BYTES = 10 << 20
+	PROOF_MAX_REDIRECTS  = 5
+
+	PROOF_SCREENSHOT_SCORE_KEYWORD = "Score"
+	PROOF_JSON_SCORE_FIELD         = "score"
+)
+
+var (
+	// competition pages embed the submission data as JSON
+	PROOF_COMPETITION_SCORE_REGEXP = regexp.MustCompile(`"(?:publicScore|privateScore|score)"\s*:\s*"?([-+]?[0-9]*\.?[0-9]+)`)
+
+	pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+)
+
+// ProofVerifier extracts the score from the fetched proof content
+type ProofVerifier interface {
+	ExtractScore(content []byte) (string, error)
+}
+
+// Proof is the content of a proof link fetched once and shared by the
+// verification and the proof registry
+type Proof struct {
+	URL     *url.URL
+	Content []byte
+}
+
+// CompetitionPageVerifier reads the score of a submission page
+type CompetitionPageVerifier struct {
+	ScoreRegexp *regexp.Regexp
+}
+
+// ScreenshotMetadataVerifier reads the score from a PNG text chunk
+type ScreenshotMetadataVerifier struct {
+	Keyword string
+}
+
+// JSONResultsVerifier reads the score by a dot separated field path
+type JSONResultsVerifier struct {
+	Field string
+}
+
+// ProofVerifierRegistry selects the verifier by the host of the proof link,
+// the client only follows the redirects to the registered hosts
+type ProofVerifierRegistry struct {
+	Client    *http.Client
+	Verifiers map[string]ProofVerifier
+}
+
+func NewProofVerifierRegistry(client *http.Client) *ProofVerifierRegistry {
+	competitionPageVerifier := &CompetitionPageVerifier{ScoreRegexp: PROOF_COMPETITION_SCORE_REGEXP}
+	screenshotMetadataVerifier := &ScreenshotMetadataVerifier{Keyword: PROOF_SCREENSHOT_SCORE_KEYWORD}
+	jsonResultsVerifier := &JSONResultsVerifier{Field: PROOF_JSON_SCORE_FIELD}
+	registry := &ProofVerifierRegistry{
+		Client: client,
+		Verifiers: map[string]ProofVerifier{
+			"kaggle.com":                 competitionPageVerifier,
+			"www.kaggle.com":             competitionPageVerifier,
+			"cdn.discordapp.com":         screenshotMetadataVerifier,
+			"media.discordapp.net":       screenshotMetadataVerifier,
+			"i.imgur.com":                screenshotMetadataVerifier,
+			"raw.githubusercontent.com":  jsonResultsVerifier,
+			"gist.githubusercontent.com": jsonResultsVerifier,
+		},
+	}
+	client.CheckRedirect = registry.checkRedirect
+	return registry
+}
+
+func (r *ProofVerifierRegistry) Register(host string, verifier ProofVerifier) {
+	r.Verifiers[strings.ToLower(host)] = verifier
+}
+
+func (r *ProofVerifierRegistry) GetVerifier(proofURL *url.URL) ProofVerifier {
+	return r.Verifiers[strings.ToLower(proofURL.Host)]
+}
+
+func (r *ProofVerifierRegistry) checkRedirect(request *http.Request, via []*http.Request) error {
+	if len(via) >= PROOF_MAX_REDIRECTS {
+		return fmt.Errorf("proof link stopped after %v redirects", PROOF_MAX_REDIRECTS)
+	}
+	if r.GetVerifier(request.URL) == nil {
+		return fmt.Errorf("proof link redirected to %v which is not an allowed host", request.URL.Host)
+	}
+	return nil
+}
+
+// Fetch downloads the proof link, only the links to the registered hosts are
+// fetched
+func (r *ProofVerifierRegistry) Fetch(ctx context.Context, proofLink string) (*Proof, error) {
+	proofURL, err := url.Parse(proofLink)
+	if err != nil {
+		return nil, err
+	}
+	if r.GetVerifier(proofURL) == nil {
+		return nil, fmt.Errorf("proof link %v is not on an allowed host", proofURL)
+	}
+	content, err := fetchProof(ctx, r.Client, proofURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Proof{URL: proofURL, Content: content}, nil
+}
+
+// Verify returns the proof status of the submit and the score extracted from
+// the proof, the proof is unverifiable if it could not be fetched or the score
+// can not be extracted
+func (r *ProofVerifierRegistry) Verify(proof *Proof, submit *Submit, codec *ScoreCodec) (string, string) {
+	if proof == nil {
+		return PROOF_STATUS_UNVERIFIABLE, ""
+	}
+	verifier := r.GetVerifier(proof.URL)
+	if verifier == nil {
+		return PROOF_STATUS_UNVERIFIABLE, ""
+	}
+	proofScore, err := verifier.ExtractScore(proof.Content)
+	if err != nil {
+		log.Error(err)
+		return PROOF_STATUS_UNVERIFIABLE, ""
+	}
+	score, subscore, err := codec.Encode(proofScore)
+	if err != nil {
+		log.Error(err)
+		return PROOF_STATUS_UNVERIFIABLE, proofScore
+	}
+	if codec.Compare(score, subscore, submit.Score, submit.Subscore) != 0 {
+		return PROOF_STATUS_MISMATCHED, proofScore
+	}
+	return PROOF_STATUS_VERIFIED, proofScore
+}
+
+func fetchProof(ctx context.Context, client *http.Client, proofURL *url.URL) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, proofURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proof link %v responded with %v", proofURL, response.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(response.Body, PROOF_MAX_BODY_BYTES))
+}
+
+func (v *CompetitionPageVerifier) ExtractScore(content []byte) (string, error) {
+	match := v.ScoreRegexp.FindSubmatch(content)
+	if match == nil {
+		return "", fmt.Errorf("no score found on the page")
+	}
+	return string(match[1]), nil
+}
+
+func (v *ScreenshotMetadataVerifier) ExtractScore(content []byte) (string, error) {
+	textChunks, err := readPNGTextChunks(content)
+	if err != nil {
+		return "", err
+	}
+	score, ok := textChunks[v.Keyword]
+	if !ok {
+		return "", fmt.Errorf("no %v metadata found in the screenshot", v.Keyword)
+	}
+	return strings.TrimSpace(score), nil
+}
+
+// readPNGTextChunks returns the keyword/text pairs of the tEXt chunks
+func readPNGTextChunks(data []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("the screenshot is not a PNG image")
+	}
+	textChunks := make(map[string]string)
+	reader := bytes.NewReader(data[len(pngSignature):])
+	for {
+		var length uint32
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return textChunks, nil
+		}
+		chunkType := make([]byte, 4)
+		if _, err := io.ReadFull(reader, chunkType); err != nil {
+			return nil, err
+		}
+		// the chunk data and the CRC must fit in the rest of the image
+		if uint64(length)+4 > uint64(reader.Len()) {
+			return nil, fmt.Errorf("the screenshot has a corrupted %v chunk", string(chunkType))
+		}
+		chunkData := make([]byte, length)
+		if _, err := io.ReadFull(reader, chunkData); err != nil {
+			return nil, err
+		}
+		// skip the CRC
+		if _, err := reader.Seek(4, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		switch string(chunkType) {
+		case "tEXt":
+			if i := bytes.IndexByte(chunkData, 0); i != -1 {
+				textChunks[string(chunkData[:i])] = string(chunkData[i+1:])
+			}
+		case "IEND":
+			return textChunks, nil
+		}
+	}
+}
+
+func (v *JSONResultsVerifier) ExtractScore(content []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	for _, field := range strings.Split(v.Field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("no %v field found in the results", v.Field)
+		}
+		if value, ok = object[field]; !ok {
+			return "", fmt.Errorf("no %v field found in the results", v.Field)
+		}
+	}
+	switch score := value.(type) {
+	case json.Number:
+		return score.String(), nil
+	case string:
+		return score, nil
+	default:
+		return "", fmt.Errorf("the %v field of the results is not a number", v.Field)
+	}
+}
+
+func PrintProofStatus(submit *Submit) string {
+	msg := ""
+	switch submit.ProofStatus {
+	case "":
+	case PROOF_STATUS_MISMATCHED:
+		msg = fmt.Sprintf(" | Verification: %v (proof shows %v)", submit.ProofStatus, submit.ProofScore)
+	default:
+		msg = fmt.Sprintf(" | Verification: %v", submit.ProofStatus)
+	}
+	if submit.ProofReused {
+		msg += " | Reused proof"
+	}
+	return msg
+}
@@ -0,0 +1,320 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/challenge-league/nakama-go/context"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	PROOF_COLLECTION = "proof_data"
+
+	PROOF_KEY_PREFIX_URL     = "url_"
+	PROOF_KEY_PREFIX_CONTENT = "content_"
+
+	PROOF_USE_SUBMIT = "submit"
+	PROOF_USE_RESULT = "result"
+)
+
+// PROOF_TRACKING_QUERY_PARAMS do not change the linked resource
+var PROOF_TRACKING_QUERY_PARAMS = []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "fbclid", "gclid", "ref"}
+
+type ProofUse struct {
+	Kind      string
+	UserID    string
+	DiscordID string
+	MatchID   string
+	DateTime  time.Time
+}
+
+// ProofRecord is stored by the system user in PROOF_COLLECTION twice: under
+// the hash of the normalised URL and under the hash of the linked content
+type ProofRecord struct {
+	NormalizedURL string
+	ContentHash   string
+	Uses          []*ProofUse
+}
+
+type ProofRegisterRequest struct {
+	NormalizedURL string
+	ContentHash   string
+	ProofUse      *ProofUse
+}
+
+// NormalizeProofLink makes the links to the same resource equal: the scheme
+// and the host are lower cased, www and the default ports are dropped, the
+// fragment and the tracking parameters are removed and the query is sorted
+func NormalizeProofLink(proofLink string) (string, error) {
+	proofURL, err := url.Parse(strings.TrimSpace(proofLink))
+	if err != nil {
+		return "", err
+	}
+	host := strings.ToLower(proofURL.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := proofURL.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := proofURL.Query()
+	for _, param := range PROOF_TRACKING_QUERY_PARAMS {
+		query.Del(param)
+	}
+
+	normalizedURL := &url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     strings.TrimRight(proofURL.EscapedPath(), "/"),
+		RawQuery: query.Encode(),
+	}
+	return normalizedURL.String(), nil
+}
+
+func getProofKey(prefix string, value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return prefix + hex.EncodeToString(hash[:])
+}
+
+// GetProofContentHash returns an empty hash if the proof was not fetched
+func GetProofContentHash(proof *Proof) string {
+	if proof == nil {
+		return ""
+	}
+	hash := sha256.Sum256(proof.Content)
+	return hex.EncodeToString(hash[:])
+}
+
+// fetchProofLink returns nil if the proof link is not on an allowed host or
+// can not be fetched
+func fetchProofLink(cmdBuilder *commandsBuilder, proofLink string) *Proof {
+	proof, err := cmdBuilder.GetProofVerifiers().Fetch(cmdBuilder.nakamaCtx.Ctx, proofLink)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+	return proof
+}
+
+func getProofRecord(cmdBuilder *commandsBuilder, key string) (*ProofRecord, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, PROOF_COLLECTION, key, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if len(storageObjects) == 0 {
+		return nil, nil
+	}
+	var proofRecord *ProofRecord
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &proofRecord); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return proofRecord, nil
+}
+
+// GetProofUseKey identifies the use, it is registered under both the URL and
+// the content key of the proof
+func GetProofUseKey(proofUse *ProofUse) string {
+	return strings.Join([]string{proofUse.UserID, proofUse.MatchID, proofUse.Kind}, "_")
+}
+
+// GetProofReuse returns the earlier uses of the proof in other matches, the
+// players of the same match may share the link to its result
+func GetProofReuse(proofRecords []*ProofRecord, proofUse *ProofUse) []*ProofUse {
+	var reuse []*ProofUse
+	seen := make(map[string]bool)
+	for _, proofRecord := range proofRecords {
+		if proofRecord == nil {
+			continue
+		}
+		for _, use := range proofRecord.Uses {
+			if use.MatchID != proofUse.MatchID && !seen[GetProofUseKey(use)] {
+				seen[GetProofUseKey(use)] = true
+				reuse = append(reuse, use)
+			}
+		}
+	}
+	return reuse
+}
+
+// DeduplicateProofRecords drops the uses already listed under an earlier
+// record and the records left with no uses
+func DeduplicateProofRecords(proofRecords []*ProofRecord) []*ProofRecord {
+	var deduplicated []*ProofRecord
+	seen := make(map[string]bool)
+	for _, proofRecord := range proofRecords {
+		var uses []*ProofUse
+		for _, use := range proofRecord.Uses {
+			if !seen[GetProofUseKey(use)] {
+				seen[GetProofUseKey(use)] = true
+				uses = append(uses, use)
+			}
+		}
+		if len(uses) > 0 {
+			deduplicated = append(deduplicated, &ProofRecord{
+				NormalizedURL: proofRecord.NormalizedURL,
+				ContentHash:   proofRecord.ContentHash,
+				Uses:          uses,
+			})
+		}
+	}
+	return deduplicated
+}
+
+func GetProofRecordMatchIDs(proofRecord *ProofRecord) []string {
+	var matchIDs []string
+	for _, use := range proofRecord.Uses {
+		if !IsStringInSlice(use.MatchID, matchIDs) {
+			matchIDs = append(matchIDs, use.MatchID)
+		}
+	}
+	return matchIDs
+}
+
+func PrintProofUses(proofUses []*ProofUse) string {
+	return ExecuteTemplate(
+		`{{range .}}>   {{.DateTime | formatTimeAsDate}} **{{.Kind}}** by <@{{.DiscordID}}> in `+"`{{.MatchID}}`"+`
+{{end}}`,
+		proofUses)
+}
+
+// checkProofReuse warns if the same link or content has already been used in
+// another match, returns the request registering this use once the submission
+// is accepted and true for the reused proof
+func checkProofReuse(cmdBuilder *commandsBuilder, cmd *cobra.Command, account *api.Account, matchID string, kind string, proofLink string, proof *Proof) (*ProofRegisterRequest, bool, error) {
+	normalizedURL, err := NormalizeProofLink(proofLink)
+	if err != nil {
+		log.Error(err)
+		return nil, false, err
+	}
+	contentHash := GetProofContentHash(proof)
+
+	var proofRecords []*ProofRecord
+	proofRecord, err := getProofRecord(cmdBuilder, getProofKey(PROOF_KEY_PREFIX_URL, normalizedURL))
+	if err != nil {
+		log.Error(err)
+		return nil, false, err
+	}
+	proofRecords = append(proofRecords, proofRecord)
+	if contentHash != "" {
+		if proofRecord, err = getProofRecord(cmdBuilder, getProofKey(PROOF_KEY_PREFIX_CONTENT, contentHash)); err != nil {
+			log.Error(err)
+			return nil, false, err
+		}
+		proofRecords = append(proofRecords, proofRecord)
+	}
+
+	proofUse := &ProofUse{
+		Kind:      kind,
+		UserID:    account.User.Id,
+		DiscordID: account.CustomId,
+		MatchID:   matchID,
+		DateTime:  time.Now().UTC(),
+	}
+	reuse := GetProofReuse(proofRecords, proofUse)
+	if len(reuse) > 0 {
+		fmt.Fprint(cmd.OutOrStdout(), "> **Warning**: the proof has already been used and is flagged for the moderators:\n"+PrintProofUses(reuse))
+	}
+
+	return &ProofRegisterRequest{
+		NormalizedURL: normalizedURL,
+		ContentHash:   contentHash,
+		ProofUse:      proofUse,
+	}, len(reuse) > 0, nil
+}
+
+func registerProof(cmdBuilder *commandsBuilder, proofRegisterRequest *ProofRegisterRequest) error {
+	payload, _ := json.Marshal(proofRegisterRequest)
+	log.Infof("%+v\n", string(payload))
+
+	if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "ProofRegister", Payload: string(payload)}); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func getSuspiciousProofRecordList(cmdBuilder *commandsBuilder) ([]*ProofRecord, error) {
+	var proofRecords []*ProofRecord
+	cursor := ""
+	for {
+		objects, nextCursor, err := listUserStorageObjectsWithCursor(cmdBuilder, PROOF_COLLECTION, context.NakamaSystemUserID, cursor)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		for _, object := range objects {
+			var proofRecord *ProofRecord
+			if err := json.Unmarshal([]byte(object.Value), &proofRecord); err != nil {
+				log.Error(err)
+				return nil, err
+			}
+			if len(GetProofRecordMatchIDs(proofRecord)) > 1 {
+				proofRecords = append(proofRecords, proofRecord)
+			}
+		}
+		if cursor = nextCursor; cursor == "" {
+			break
+		}
+	}
+	sort.Slice(proofRecords, func(i, j int) bool {
+		return len(proofRecords[i].Uses) > len(proofRecords[j].Uses)
+	})
+	return proofRecords, nil
+}
+
+func getCmdAdminProofReport(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proofs",
+		Short: "Report the **proofs reused** across matches",
+		Long:  `Report the **proofs reused** across matches, the same link or the same content submitted in more than one match`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			proofRecords, err := getSuspiciousProofRecordList(cmdBuilder)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(proofRecords) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No proofs reused across matches found")
+				return nil
+			}
+
+			msg := "> Proofs reused across matches:\n"
+			for _, proofRecord := range DeduplicateProofRecords(proofRecords) {
+				msg += fmt.Sprintf("> %v", proofRecord.NormalizedURL)
+				if proofRecord.ContentHash != "" {
+					msg += fmt.Sprintf(" (content `%.12v`)", proofRecord.ContentHash)
+				}
+				msg += "\n" + PrintProofUses(proofRecord.Uses)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	return cmd
+}
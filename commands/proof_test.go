@@ -0,0 +1,224 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func newPNGChunk(chunkType string, data []byte) []byte {
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(len(data)))
+	chunk.WriteString(chunkType)
+	chunk.Write(data)
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(chunkType), data...)))
+	return chunk.Bytes()
+}
+
+func newPNGScreenshot(chunks ...[]byte) []byte {
+	screenshot := append([]byte{}, pngSignature...)
+	for _, chunk := range chunks {
+		screenshot = append(screenshot, chunk...)
+	}
+	return append(screenshot, newPNGChunk("IEND", nil)...)
+}
+
+func newTestProofVerifierRegistry(t *testing.T, verifier ProofVerifier, handler http.Handler) (*ProofVerifierRegistry, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	registry := NewProofVerifierRegistry(server.Client())
+	registry.Register(server.Listener.Addr().String(), verifier)
+	return registry, server
+}
+
+func TestProofVerifiers(t *testing.T) {
+	tests := []struct {
+		name        string
+		verifier    ProofVerifier
+		contentType string
+		body        []byte
+		score       string
+		status      string
+	}{
+		{
+			name:        "competition page",
+			verifier:    &CompetitionPageVerifier{ScoreRegexp: PROOF_COMPETITION_SCORE_REGEXP},
+			contentType: "text/html",
+			body:        []byte(`<script>var submission = {"id": 1, "publicScore": "0.9876"};</script>`),
+			score:       "0.9876",
+			status:      PROOF_STATUS_VERIFIED,
+		},
+		{
+			name:        "competition page mismatch",
+			verifier:    &CompetitionPageVerifier{ScoreRegexp: PROOF_COMPETITION_SCORE_REGEXP},
+			contentType: "text/html",
+			body:        []byte(`<script>var submission = {"score": 0.5};</script>`),
+			score:       "0.9876",
+			status:      PROOF_STATUS_MISMATCHED,
+		},
+		{
+			name:        "competition page without score",
+			verifier:    &CompetitionPageVerifier{ScoreRegexp: PROOF_COMPETITION_SCORE_REGEXP},
+			contentType: "text/html",
+			body:        []byte(`<html></html>`),
+			score:       "0.9876",
+			status:      PROOF_STATUS_UNVERIFIABLE,
+		},
+		{
+			name:        "screenshot metadata",
+			verifier:    &ScreenshotMetadataVerifier{Keyword: PROOF_SCREENSHOT_SCORE_KEYWORD},
+			contentType: "image/png",
+			body:        newPNGScreenshot(newPNGChunk("tEXt", []byte("Score\x00 1234.5 "))),
+			score:       "1234.5",
+			status:      PROOF_STATUS_VERIFIED,
+		},
+		{
+			name:        "screenshot without metadata",
+			verifier:    &ScreenshotMetadataVerifier{Keyword: PROOF_SCREENSHOT_SCORE_KEYWORD},
+			contentType: "image/png",
+			body:        newPNGScreenshot(newPNGChunk("tEXt", []byte("Author\x00player"))),
+			score:       "1234.5",
+			status:      PROOF_STATUS_UNVERIFIABLE,
+		},
+		{
+			name:        "screenshot with oversized chunk",
+			verifier:    &ScreenshotMetadataVerifier{Keyword: PROOF_SCREENSHOT_SCORE_KEYWORD},
+			contentType: "image/png",
+			body:        append(append([]byte{}, pngSignature...), 0xff, 0xff, 0xff, 0xff, 't', 'E', 'X', 't'),
+			score:       "1234.5",
+			status:      PROOF_STATUS_UNVERIFIABLE,
+		},
+		{
+			name:        "json results",
+			verifier:    &JSONResultsVerifier{Field: "results.score"},
+			contentType: "application/json",
+			body:        []byte(`{"results": {"score": 42}}`),
+			score:       "42",
+			status:      PROOF_STATUS_VERIFIED,
+		},
+		{
+			name:        "json results mismatch",
+			verifier:    &JSONResultsVerifier{Field: "results.score"},
+			contentType: "application/json",
+			body:        []byte(`{"results": {"score": "41"}}`),
+			score:       "42",
+			status:      PROOF_STATUS_MISMATCHED,
+		},
+		{
+			name:        "json results without field",
+			verifier:    &JSONResultsVerifier{Field: "results.score"},
+			contentType: "application/json",
+			body:        []byte(`{"score": 42}`),
+			score:       "42",
+			status:      PROOF_STATUS_UNVERIFIABLE,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry, server := newTestProofVerifierRegistry(t, test.verifier, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", test.contentType)
+				w.Write(test.body)
+			}))
+			score, subscore, err := DEFAULT_SCORE_CODEC.Encode(test.score)
+			if err != nil {
+				t.Fatal(err)
+			}
+			submit := &Submit{Score: score, Subscore: subscore, ProofLink: server.URL + "/proof"}
+			proof, err := registry.Fetch(context.Background(), submit.ProofLink)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status, proofScore := registry.Verify(proof, submit, DEFAULT_SCORE_CODEC); status != test.status {
+				t.Errorf("Verify() = %v (%v), want %v", status, proofScore, test.status)
+			}
+		})
+	}
+}
+
+func TestProofVerifierRegistryUnknownHost(t *testing.T) {
+	registry := NewProofVerifierRegistry(&http.Client{})
+	proofURL, _ := url.Parse("http://127.0.0.1:1/proof")
+	if verifier := registry.GetVerifier(proofURL); verifier != nil {
+		t.Errorf("GetVerifier() = %v, want nil", verifier)
+	}
+	if proof, err := registry.Fetch(context.Background(), proofURL.String()); err == nil {
+		t.Errorf("Fetch() = %v, want an error", proof)
+	}
+}
+
+func TestProofVerifierRegistryRedirects(t *testing.T) {
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"score": 42}`))
+	}))
+	defer otherServer.Close()
+
+	registry, server := newTestProofVerifierRegistry(t, &JSONResultsVerifier{Field: "score"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/proof", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, otherServer.URL+"/proof", http.StatusFound)
+		default:
+			w.Write([]byte(`{"score": 42}`))
+		}
+	}))
+	score, subscore, _ := DEFAULT_SCORE_CODEC.Encode("42")
+
+	submit := &Submit{Score: score, Subscore: subscore, ProofLink: server.URL + "/moved"}
+	proof, err := registry.Fetch(context.Background(), submit.ProofLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := registry.Verify(proof, submit, DEFAULT_SCORE_CODEC); status != PROOF_STATUS_VERIFIED {
+		t.Errorf("Verify() = %v, want %v", status, PROOF_STATUS_VERIFIED)
+	}
+	if proof, err := registry.Fetch(context.Background(), server.URL+"/away"); err == nil {
+		t.Errorf("Fetch() = %v, want an error for the redirect to %v", proof, otherServer.URL)
+	}
+}
+
+func TestDeduplicateProofRecords(t *testing.T) {
+	first := &ProofUse{Kind: PROOF_USE_SUBMIT, UserID: "user1", MatchID: "match1"}
+	second := &ProofUse{Kind: PROOF_USE_SUBMIT, UserID: "user2", MatchID: "match2"}
+	third := &ProofUse{Kind: PROOF_USE_RESULT, UserID: "user2", MatchID: "match2"}
+	proofRecords := []*ProofRecord{
+		{NormalizedURL: "https://example.com/proof", Uses: []*ProofUse{first, second}},
+		{NormalizedURL: "https://example.com/proof", ContentHash: "hash", Uses: []*ProofUse{first, second, third}},
+		{NormalizedURL: "https://example.com/other", ContentHash: "hash", Uses: []*ProofUse{second}},
+	}
+
+	deduplicated := DeduplicateProofRecords(proofRecords)
+	if len(deduplicated) != 2 {
+		t.Fatalf("%v records, want 2", len(deduplicated))
+	}
+	if len(deduplicated[0].Uses) != 2 || len(deduplicated[1].Uses) != 1 || deduplicated[1].Uses[0] != third {
+		t.Errorf("uses %v and %v, want the first two and then only the result", deduplicated[0].Uses, deduplicated[1].Uses)
+	}
+	if len(proofRecords[1].Uses) != 3 {
+		t.Errorf("the records are changed")
+	}
+
+	if reuse := GetProofReuse(proofRecords, &ProofUse{Kind: PROOF_USE_SUBMIT, UserID: "user3", MatchID: "match3"}); len(reuse) != 3 {
+		t.Errorf("%v reuses, want 3", len(reuse))
+	}
+}
@@ -18,6 +18,7 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/api"
 	log "github.com/micro/go-micro/v2/logger"
@@ -39,7 +40,7 @@ type UserReady struct {
 func PrintMatchReadyUserIDs(matchState *MatchState) string {
 	teamUsersReady := GetUsersReady(matchState)
 	if matchState.Status == MATCH_STATUS_AWAITNG_USERS_READY {
-		msg := ""
+		msg := PrintReadyDeadline(matchState)
 		if len(teamUsersReady) > 0 {
 			msg += "> Ready:\n"
 			for teamNumber, usersReady := range teamUsersReady {
@@ -118,6 +119,15 @@ Player can be ready even before a new match is found`,
 				return nil
 			}
 
+			matchState, err := getMatchState(cmdBuilder, ticketState.MatchID, MATCH_COLLECTION)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchState != nil && IsReadyDeadlineExpired(matchState, time.Now().UTC()) {
+				return fmt.Errorf("The ready deadline of the match **%v** has passed, the server finalises the match", matchState.MatchID)
+			}
+
 			payload, _ := json.Marshal(MatchReadyRequest{
 				MatchID: ticketState.MatchID,
 				UserID:  account.User.Id,
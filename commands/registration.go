@@ -0,0 +1,618 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/challenge-league/nakama-go/context"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	REGISTRATION_COLLECTION = "tournament_registration_data"
+
+	REGISTRATION_STATUS_REGISTERED = "registered"
+	REGISTRATION_STATUS_WAITLISTED = "waitlisted"
+	REGISTRATION_STATUS_WITHDRAWN  = "withdrawn"
+	REGISTRATION_STATUS_DROPPED    = "dropped"
+
+	DEFAULT_CHECK_IN_MINUTES = 60
+	MIN_CHECK_IN_MINUTES     = 5
+	MAX_CHECK_IN_MINUTES     = 24 * 60
+)
+
+type Registration struct {
+	UserID           string
+	DiscordID        string
+	Username         string
+	Status           string
+	CheckedIn        bool
+	DateTimeRegister time.Time
+	DateTimeCheckIn  time.Time
+}
+
+// TournamentRegistration keeps the registrations in the order of arrival,
+// the waitlist is promoted in this order
+type TournamentRegistration struct {
+	TournamentID  string
+	MaxSize       int
+	DateTimeOpen  time.Time
+	DateTimeClose time.Time
+	DateTimeStart time.Time
+	CheckIn       time.Duration
+	Registrations []*Registration
+	Finalized     bool
+}
+
+type TournamentRegistrationUpdateRequest struct {
+	TournamentRegistration *TournamentRegistration
+	Version                string
+}
+
+// TournamentRegistrationScheduleRequest makes the server finalize the
+// registration at its DateTimeStart, it replaces the finalization scheduled
+// for the previous start
+type TournamentRegistrationScheduleRequest struct {
+	TournamentID string
+}
+
+// TournamentJoinRequest joins the user to the tournament on the server, the
+// users promoted from the waitlist do not run a command themselves
+type TournamentJoinRequest struct {
+	ID     string
+	UserID string
+}
+
+func (r *TournamentRegistration) GetCheckInOpen() time.Time {
+	return r.DateTimeStart.Add(-r.CheckIn)
+}
+
+func (r *TournamentRegistration) IsOpen(now time.Time) bool {
+	return !now.Before(r.DateTimeOpen) && now.Before(r.DateTimeClose)
+}
+
+func (r *TournamentRegistration) IsCheckInOpen(now time.Time) bool {
+	return !now.Before(r.GetCheckInOpen()) && now.Before(r.DateTimeStart)
+}
+
+func (r *TournamentRegistration) GetRegistration(userID string) *Registration {
+	for _, registration := range r.Registrations {
+		if registration.UserID == userID {
+			return registration
+		}
+	}
+	return nil
+}
+
+func (r *TournamentRegistration) GetRegistrations(status string) []*Registration {
+	var registrations []*Registration
+	for _, registration := range r.Registrations {
+		if registration.Status == status {
+			registrations = append(registrations, registration)
+		}
+	}
+	return registrations
+}
+
+func (r *TournamentRegistration) IsFull() bool {
+	return r.MaxSize > 0 && len(r.GetRegistrations(REGISTRATION_STATUS_REGISTERED)) >= r.MaxSize
+}
+
+// GetWaitlistPosition is 1 for the next user to be promoted
+func (r *TournamentRegistration) GetWaitlistPosition(userID string) int {
+	for i, registration := range r.GetRegistrations(REGISTRATION_STATUS_WAITLISTED) {
+		if registration.UserID == userID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Register adds the user to the tournament or to the waitlist when the
+// tournament is full, a withdrawn user registers again at the end
+func (r *TournamentRegistration) Register(account *api.Account, now time.Time) (*Registration, error) {
+	if !r.IsOpen(now) {
+		return nil, fmt.Errorf("The registration is open from %v to %v", formatTimeAsDate(r.DateTimeOpen), formatTimeAsDate(r.DateTimeClose))
+	}
+	registration := r.GetRegistration(account.User.Id)
+	switch {
+	case registration == nil:
+		registration = &Registration{
+			UserID:    account.User.Id,
+			DiscordID: account.CustomId,
+			Username:  account.User.Username,
+		}
+	case registration.Status == REGISTRATION_STATUS_WITHDRAWN:
+		r.remove(registration)
+		registration.CheckedIn = false
+	default:
+		return nil, fmt.Errorf("<@%v> is already %v", account.CustomId, registration.Status)
+	}
+	registration.DateTimeRegister = now
+	registration.Status = REGISTRATION_STATUS_REGISTERED
+	if r.IsFull() {
+		registration.Status = REGISTRATION_STATUS_WAITLISTED
+	}
+	r.Registrations = append(r.Registrations, registration)
+	return registration, nil
+}
+
+func (r *TournamentRegistration) remove(registration *Registration) {
+	for i, v := range r.Registrations {
+		if v == registration {
+			r.Registrations = append(r.Registrations[:i], r.Registrations[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *TournamentRegistration) CheckInUser(userID string, now time.Time) (*Registration, error) {
+	if !r.IsCheckInOpen(now) {
+		return nil, fmt.Errorf("The check-in is open from %v to %v", formatTimeAsDate(r.GetCheckInOpen()), formatTimeAsDate(r.DateTimeStart))
+	}
+	registration := r.GetRegistration(userID)
+	if registration == nil || (registration.Status != REGISTRATION_STATUS_REGISTERED && registration.Status != REGISTRATION_STATUS_WAITLISTED) {
+		return nil, fmt.Errorf("You are not registered for the tournament")
+	}
+	if registration.CheckedIn {
+		return nil, fmt.Errorf("You have already checked in")
+	}
+	registration.CheckedIn = true
+	registration.DateTimeCheckIn = now
+	return registration, nil
+}
+
+// promote moves the waitlisted users into the free places, only the
+// checked-in ones once the check-in is over
+func (r *TournamentRegistration) promote(checkedInOnly bool) []*Registration {
+	var promoted []*Registration
+	for _, registration := range r.GetRegistrations(REGISTRATION_STATUS_WAITLISTED) {
+		if r.IsFull() {
+			break
+		}
+		if checkedInOnly && !registration.CheckedIn {
+			continue
+		}
+		registration.Status = REGISTRATION_STATUS_REGISTERED
+		promoted = append(promoted, registration)
+	}
+	return promoted
+}
+
+func (r *TournamentRegistration) Withdraw(userID string) (*Registration, []*Registration, error) {
+	registration := r.GetRegistration(userID)
+	if registration == nil || (registration.Status != REGISTRATION_STATUS_REGISTERED && registration.Status != REGISTRATION_STATUS_WAITLISTED) {
+		return nil, nil, fmt.Errorf("You are not registered for the tournament")
+	}
+	if r.Finalized {
+		return nil, nil, fmt.Errorf("The tournament has already started")
+	}
+	registration.Status = REGISTRATION_STATUS_WITHDRAWN
+	return registration, r.promote(false), nil
+}
+
+// Finalize drops the registered users who missed the check-in and replaces
+// them with the checked-in users from the waitlist once the tournament starts
+func (r *TournamentRegistration) Finalize(now time.Time) ([]*Registration, []*Registration) {
+	if r.Finalized || now.Before(r.DateTimeStart) {
+		return nil, nil
+	}
+	var dropped []*Registration
+	for _, registration := range r.GetRegistrations(REGISTRATION_STATUS_REGISTERED) {
+		if !registration.CheckedIn {
+			registration.Status = REGISTRATION_STATUS_DROPPED
+			dropped = append(dropped, registration)
+		}
+	}
+	promoted := r.promote(true)
+	for _, registration := range r.GetRegistrations(REGISTRATION_STATUS_WAITLISTED) {
+		if !registration.CheckedIn {
+			registration.Status = REGISTRATION_STATUS_DROPPED
+		}
+	}
+	r.Finalized = true
+	return dropped, promoted
+}
+
+// GetParticipantIDs are the registered users who checked in
+func (r *TournamentRegistration) GetParticipantIDs() []string {
+	var userIDs []string
+	for _, registration := range r.GetRegistrations(REGISTRATION_STATUS_REGISTERED) {
+		if registration.CheckedIn {
+			userIDs = append(userIDs, registration.UserID)
+		}
+	}
+	return userIDs
+}
+
+func PrintRegistration(r *TournamentRegistration, registration *Registration) string {
+	msg := fmt.Sprintf("<@%v> **%v**", registration.DiscordID, registration.Status)
+	if position := r.GetWaitlistPosition(registration.UserID); position > 0 {
+		msg += fmt.Sprintf(", waitlist position **%v**", position)
+	}
+	if registration.CheckedIn {
+		msg += ", checked in"
+	}
+	return msg
+}
+
+func PrintTournamentRegistration(r *TournamentRegistration, userID string) string {
+	size := fmt.Sprintf("%v", len(r.GetRegistrations(REGISTRATION_STATUS_REGISTERED)))
+	if r.MaxSize > 0 {
+		size += fmt.Sprintf("/%v", r.MaxSize)
+	}
+	msg := fmt.Sprintf("> Registration: **%v** - **%v**, registered **%v**, waitlist **%v**\n",
+		formatTimeAsDate(r.DateTimeOpen), formatTimeAsDate(r.DateTimeClose), size, len(r.GetRegistrations(REGISTRATION_STATUS_WAITLISTED)))
+	msg += fmt.Sprintf("> Check-in: **%v** - **%v**\n", formatTimeAsDate(r.GetCheckInOpen()), formatTimeAsDate(r.DateTimeStart))
+	if registration := r.GetRegistration(userID); registration != nil {
+		msg += "> " + PrintRegistration(r, registration) + "\n"
+	}
+	return msg
+}
+
+func getTournamentRegistration(cmdBuilder *commandsBuilder, tournamentID string) (*TournamentRegistration, string, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, REGISTRATION_COLLECTION, tournamentID, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	if len(storageObjects) == 0 {
+		return nil, "", nil
+	}
+	var tournamentRegistration *TournamentRegistration
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &tournamentRegistration); err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	return tournamentRegistration, storageObjects[0].Version, nil
+}
+
+// updateTournamentRegistration writes the registration if nobody has changed
+// it since it was read
+func updateTournamentRegistration(cmdBuilder *commandsBuilder, tournamentRegistration *TournamentRegistration, version string) error {
+	payload, _ := json.Marshal(&TournamentRegistrationUpdateRequest{
+		TournamentRegistration: tournamentRegistration,
+		Version:                version,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentRegistrationUpdate", Payload: string(payload)}); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// joinTournamentUsers joins the promoted users to the tournament
+func joinTournamentUsers(cmdBuilder *commandsBuilder, tournamentID string, registrations []*Registration) error {
+	for _, registration := range registrations {
+		payload, _ := json.Marshal(&TournamentJoinRequest{
+			ID:     tournamentID,
+			UserID: registration.UserID,
+		})
+		log.Infof("%+v\n", string(payload))
+
+		if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentJoin", Payload: string(payload)}); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// leaveTournamentUsers removes the withdrawn and the dropped users from the
+// tournament
+func leaveTournamentUsers(cmdBuilder *commandsBuilder, tournamentID string, registrations []*Registration) error {
+	for _, registration := range registrations {
+		payload, _ := json.Marshal(&TournamentLeaveRequest{
+			ID:     tournamentID,
+			UserID: registration.UserID,
+		})
+		log.Infof("%+v\n", string(payload))
+
+		if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentLeave", Payload: string(payload)}); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// getFinalizedTournamentRegistration finalizes the registration if the
+// server has not done it at the start yet
+func getFinalizedTournamentRegistration(cmdBuilder *commandsBuilder, tournamentID string) (*TournamentRegistration, string, error) {
+	tournamentRegistration, version, err := getTournamentRegistration(cmdBuilder, tournamentID)
+	if err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	if tournamentRegistration == nil || tournamentRegistration.Finalized || now.Before(tournamentRegistration.DateTimeStart) {
+		return tournamentRegistration, version, nil
+	}
+
+	dropped, promoted := tournamentRegistration.Finalize(now)
+	log.Infof("%v dropped, %v promoted from the waitlist", len(dropped), len(promoted))
+	if err := updateTournamentRegistration(cmdBuilder, tournamentRegistration, version); err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	if err := leaveTournamentUsers(cmdBuilder, tournamentID, dropped); err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	if err := joinTournamentUsers(cmdBuilder, tournamentID, promoted); err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	return getTournamentRegistration(cmdBuilder, tournamentID)
+}
+
+func registerTournamentUser(cmdBuilder *commandsBuilder, tournament *api.Tournament, account *api.Account) (string, error) {
+	tournamentRegistration, version, err := getFinalizedTournamentRegistration(cmdBuilder, tournament.Id)
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+	if tournamentRegistration == nil {
+		return "", fmt.Errorf("The tournament **%v** has no registration, join it with **dl tournament join %v**", tournament.Title, tournament.Id)
+	}
+	registration, err := tournamentRegistration.Register(account, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	if err := updateTournamentRegistration(cmdBuilder, tournamentRegistration, version); err != nil {
+		log.Error(err)
+		return "", err
+	}
+	// the waitlisted users join once they are promoted
+	if registration.Status == REGISTRATION_STATUS_REGISTERED {
+		if _, err := cmdBuilder.nakamaCtx.Client.JoinTournament(cmdBuilder.nakamaCtx.Ctx, &api.JoinTournamentRequest{
+			TournamentId: tournament.Id,
+		}); err != nil {
+			log.Error(err)
+			return "", err
+		}
+	}
+	return fmt.Sprintf("> Tournament **%v**: %v\n", tournament.Title, PrintRegistration(tournamentRegistration, registration)) +
+		PrintTournamentRegistration(tournamentRegistration, ""), nil
+}
+
+// withdrawTournamentUser withdraws the user and gives the place to the
+// first users on the waitlist
+func withdrawTournamentUser(cmdBuilder *commandsBuilder, tournament *api.Tournament, tournamentRegistration *TournamentRegistration, version string, account *api.Account) (string, error) {
+	var left []*Registration
+	if registration := tournamentRegistration.GetRegistration(account.User.Id); registration != nil && registration.Status == REGISTRATION_STATUS_REGISTERED {
+		left = append(left, registration)
+	}
+	registration, promoted, err := tournamentRegistration.Withdraw(account.User.Id)
+	if err != nil {
+		return "", err
+	}
+	if err := updateTournamentRegistration(cmdBuilder, tournamentRegistration, version); err != nil {
+		log.Error(err)
+		return "", err
+	}
+	if err := leaveTournamentUsers(cmdBuilder, tournament.Id, left); err != nil {
+		log.Error(err)
+		return "", err
+	}
+	if err := joinTournamentUsers(cmdBuilder, tournament.Id, promoted); err != nil {
+		log.Error(err)
+		return "", err
+	}
+	msg := fmt.Sprintf("> Tournament **%v**: %v\n", tournament.Title, PrintRegistration(tournamentRegistration, registration))
+	for _, promotedRegistration := range promoted {
+		msg += fmt.Sprintf("> <@%v> is promoted from the waitlist\n", promotedRegistration.DiscordID)
+	}
+	return msg, nil
+}
+
+func getCmdTournamentRegister(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "register [id]",
+		Short: "Register for the **tournament**",
+		Long: `Register for the **tournament**, you are put on the waitlist when the tournament is full
+Every registered user has to **dl tournament checkin** before the start or is replaced from the waitlist`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			msg, err := registerTournamentUser(cmdBuilder, tournament, account)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func getCmdTournamentCheckIn(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checkin [id]",
+		Short: "Check in for the **tournament**",
+		Long: `Check in for the **tournament** during the check-in window before the start
+The waitlisted users who check in replace the registered users who miss the check-in`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournamentRegistration, version, err := getFinalizedTournamentRegistration(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if tournamentRegistration == nil {
+				return fmt.Errorf("The tournament **%v** has no registration", tournament.Title)
+			}
+			registration, err := tournamentRegistration.CheckInUser(account.User.Id, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			if err := updateTournamentRegistration(cmdBuilder, tournamentRegistration, version); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), fmt.Sprintf("> Tournament **%v**: %v\n", tournament.Title, PrintRegistration(tournamentRegistration, registration)))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func getCmdTournamentWithdraw(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "withdraw [id]",
+		Short: "Withdraw from the **tournament**",
+		Long:  `Withdraw from the **tournament** before the start, your place goes to the first user on the waitlist`,
+		Args:  matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournamentRegistration, version, err := getFinalizedTournamentRegistration(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if tournamentRegistration == nil {
+				return fmt.Errorf("The tournament **%v** has no registration", tournament.Title)
+			}
+			msg, err := withdrawTournamentUser(cmdBuilder, tournament, tournamentRegistration, version, account)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func getCmdTournamentRegistration(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registration [id]",
+		Short: "Set the **registration** and check-in times of the tournament",
+		Long: `Set the **registration** and check-in times of the tournament
+The check-in window closes at the start, the registrations are kept when the times change`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournamentRegistration, version, err := getTournamentRegistration(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if tournamentRegistration == nil {
+				tournamentRegistration = &TournamentRegistration{TournamentID: tournament.Id}
+			}
+			if tournamentRegistration.Finalized {
+				return fmt.Errorf("The tournament **%v** has already started", tournament.Title)
+			}
+			tournamentRegistration.MaxSize = int(tournament.MaxSize)
+
+			if tournamentRegistration.DateTimeOpen, err = parseDateTimeFlag(cmd, "open"); err != nil {
+				return err
+			}
+			if tournamentRegistration.DateTimeOpen.IsZero() {
+				tournamentRegistration.DateTimeOpen = time.Now().UTC()
+			}
+			if tournamentRegistration.DateTimeStart, err = parseDateTimeFlag(cmd, "start"); err != nil {
+				return err
+			}
+			if tournamentRegistration.DateTimeStart.IsZero() && tournament.StartTime != nil {
+				tournamentRegistration.DateTimeStart = tournament.StartTime.AsTime().UTC()
+			}
+			if !tournamentRegistration.DateTimeStart.After(time.Now().UTC()) {
+				return fmt.Errorf("Please specify the **--start** of the tournament in the future")
+			}
+			if tournamentRegistration.DateTimeClose, err = parseDateTimeFlag(cmd, "close"); err != nil {
+				return err
+			}
+			if tournamentRegistration.DateTimeClose.IsZero() {
+				tournamentRegistration.DateTimeClose = tournamentRegistration.DateTimeStart
+			}
+			if !tournamentRegistration.DateTimeClose.After(tournamentRegistration.DateTimeOpen) || tournamentRegistration.DateTimeClose.After(tournamentRegistration.DateTimeStart) {
+				return fmt.Errorf("The registration must close after it opens and no later than the start")
+			}
+			checkIn, _ := cmd.Flags().GetInt("checkIn")
+			if checkIn < MIN_CHECK_IN_MINUTES || checkIn > MAX_CHECK_IN_MINUTES {
+				return fmt.Errorf("checkIn must be between %v and %v minutes", MIN_CHECK_IN_MINUTES, MAX_CHECK_IN_MINUTES)
+			}
+			tournamentRegistration.CheckIn = time.Duration(checkIn) * time.Minute
+
+			if err := updateTournamentRegistration(cmdBuilder, tournamentRegistration, version); err != nil {
+				log.Error(err)
+				return err
+			}
+			payload, _ := json.Marshal(&TournamentRegistrationScheduleRequest{
+				TournamentID: tournament.Id,
+			})
+			log.Infof("%+v\n", string(payload))
+			if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentRegistrationSchedule", Payload: string(payload)}); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), fmt.Sprintf("> Tournament **%v**\n", tournament.Title)+PrintTournamentRegistration(tournamentRegistration, ""))
+			return nil
+		},
+	}
+	cmd.Flags().StringP("open", "", "", "Registration open time, now by default: "+SCHEDULE_TIME_LAYOUT)
+	cmd.Flags().StringP("close", "", "", "Registration close time, the start by default: "+SCHEDULE_TIME_LAYOUT)
+	cmd.Flags().StringP("start", "", "", "Tournament start time, the tournament start by default: "+SCHEDULE_TIME_LAYOUT)
+	cmd.Flags().StringP("tz", "", SCHEDULE_DEFAULT_TZ, "Time zone of the times, e.g. Europe/Berlin")
+	cmd.Flags().IntP("checkIn", "c", DEFAULT_CHECK_IN_MINUTES, "Check-in window before the start in minutes")
+	return cmd
+}
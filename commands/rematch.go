@@ -0,0 +1,209 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"open-match.dev/open-match/pkg/pb"
+)
+
+// CloneTeams copies the line-up of the teams without the rewards, tickets
+// and discord channels of the previous match
+func CloneTeams(teams []*Team) []*Team {
+	var clonedTeams []*Team
+	for _, team := range teams {
+		clonedTeam := &Team{
+			ID:   team.ID,
+			Name: team.Name,
+		}
+		for _, teamUser := range team.TeamUsers {
+			clonedTeam.TeamUsers = append(clonedTeam.TeamUsers, &TeamUser{
+				User:    teamUser.User,
+				Captain: teamUser.Captain,
+			})
+		}
+		clonedTeams = append(clonedTeams, clonedTeam)
+	}
+	return clonedTeams
+}
+
+// getLastArchivedMatchState looks up the last match of the user data first and
+// falls back to the latest entry of the match history of the user
+func getLastArchivedMatchState(cmdBuilder *commandsBuilder, account *api.Account) (*MatchState, error) {
+	userData, err := getLastUserData(cmdBuilder, account)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if userData != nil && userData.MatchID != "" {
+		matchState, err := getMatchState(cmdBuilder, userData.MatchID, MATCH_ARCHIVE_COLLECTION)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		if matchState != nil {
+			return matchState, nil
+		}
+	}
+
+	matchStateList, _, err := getArchivedMatchStateList(cmdBuilder, &MatchHistoryFilter{
+		UserID:          account.User.Id,
+		MaxResultsCount: 1,
+	}, "")
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if len(matchStateList) == 0 {
+		return nil, nil
+	}
+	return matchStateList[0], nil
+}
+
+func getCmdRematch(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rematch [matchID]",
+		Short: "Start a **rematch** with the same teams, mode and duration",
+		Long: `Start a **rematch** of a finished Captains Draft match with the same teams, mode and duration
+Every player has to confirm the rematch with **dl ready**`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			var matchState *MatchState
+			if len(args) > 0 {
+				matchState, err = getMatchState(cmdBuilder, args[0], MATCH_ARCHIVE_COLLECTION)
+			} else {
+				matchState, err = getLastArchivedMatchState(cmdBuilder, account)
+			}
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchState == nil {
+				return fmt.Errorf("No finished match found for <@%v>, find it with **dl history** and use **dl rematch <matchID>**", account.CustomId)
+			}
+			if matchState.Active || matchState.Status == MATCH_STATUS_CANCELED {
+				return fmt.Errorf("The match **%v** is not completed", matchState.MatchID)
+			}
+			if !isCaptainsDraft(matchState.MatchType) {
+				return fmt.Errorf("Only Captains Draft matches can be rematched")
+			}
+			if !IsUserIDInMatch(account.User.Id, matchState) {
+				return fmt.Errorf("<@%v> did not play in the match **%v**", account.CustomId, matchState.MatchID)
+			}
+
+			readyDeadline, _ := cmd.Flags().GetInt("ready-deadline")
+			if readyDeadline < MIN_READY_DEADLINE_MINUTES || readyDeadline > MAX_READY_DEADLINE_MINUTES {
+				return fmt.Errorf("ready deadline must be between %v and %v minutes", MIN_READY_DEADLINE_MINUTES, MAX_READY_DEADLINE_MINUTES)
+			}
+
+			var accounts []*api.Account
+			for _, teamUser := range GetTeamUsersFromMatch(matchState) {
+				userAccount, err := getAccountByDiscordID(cmdBuilder, teamUser.User.Nakama.CustomID)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				lastTicketState, err := getLastUserTicketState(cmdBuilder, userAccount)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				if err := checkUserCooldown(cmdBuilder, userAccount); err != nil {
+					return err
+				}
+				if lastTicketState != nil {
+					fmt.Fprintf(cmd.OutOrStdout(),
+						fmt.Sprintf("<@%v> already has a ticket. Please cancel the following ticket or finish the following match:\n"+
+							PrintTicketState(lastTicketState), userAccount.CustomId))
+					return nil
+				}
+				accounts = append(accounts, userAccount)
+			}
+
+			matchID := uuid.Must(uuid.NewV4()).String()
+			duration := int(math.Round(matchState.Duration.Hours()))
+			leaderboardID := GetMatchLeaderboardID(matchState.MatchProfile, matchState.MatchType, matchState.Duration)
+			if err := ensureLeaderboard(cmdBuilder, leaderboardID); err != nil {
+				log.Error(err)
+				return err
+			}
+			seasonLeaderboardID, err := getCurrentSeasonLeaderboardID(cmdBuilder)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			teams := CloneTeams(matchState.Teams)
+
+			var tickets []*pb.Ticket
+			msg := ""
+			for _, userAccount := range accounts {
+				ticketState, err := newCaptainsDraftTicketState(cmdBuilder, userAccount, matchID, false, duration, []string{matchState.MatchProfile})
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				if teamUser, _ := GetUserAndTeamNumberByUserID(userAccount.User.Id, &MatchState{Teams: teams}); teamUser != nil {
+					teamUser.TicketID = ticketState.Ticket.Id
+				}
+				tickets = append(tickets, ticketState.Ticket)
+				msg += PrintTicketState(ticketState)
+			}
+
+			match := &pb.Match{
+				MatchId:      matchID,
+				MatchProfile: matchState.MatchProfile,
+				Tickets:      tickets,
+				Extensions: map[string]*anypb.Any{
+					MATCH_EXTENSION_MATCH_TYPE:            &anypb.Any{Value: Marshal(matchState.MatchType)},
+					MATCH_EXTENSION_TEAMS:                 &anypb.Any{Value: Marshal(teams)},
+					MATCH_EXTENSION_REMATCH_OF:            &anypb.Any{Value: Marshal(matchState.MatchID)},
+					MATCH_EXTENSION_ROUTED_LEADERBOARD_ID: &anypb.Any{Value: Marshal(leaderboardID)},
+					MATCH_EXTENSION_READY_DEADLINE:        &anypb.Any{Value: Marshal(time.Duration(readyDeadline) * time.Minute)},
+				},
+			}
+			if seasonLeaderboardID != "" {
+				match.Extensions[MATCH_EXTENSION_SEASON_LEADERBOARD_ID] = &anypb.Any{Value: Marshal(seasonLeaderboardID)}
+			}
+
+			result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchCreate", Payload: string(Marshal(match))})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			log.Infof("%+v\n", MarshalIndent(result))
+
+			fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("> **Rematch** of the match **%v** is created, every player has to type **dl ready**\n", matchState.MatchID)+msg)
+			return nil
+		},
+	}
+	cmd.Flags().IntP("ready-deadline", "", DEFAULT_READY_DEADLINE_MINUTES, "Minutes the players have to get ready, not ready players forfeit the match")
+	return cmd
+}
@@ -34,13 +34,14 @@ type TeamResult struct {
 }
 
 type MatchResult struct {
-	UserID     string
-	DiscordID  string
-	ProofLink  string
-	TeamNumber int
-	Win        bool
-	Draw       bool
-	DateTime   time.Time
+	UserID      string
+	DiscordID   string
+	ProofLink   string
+	ProofReused bool
+	TeamNumber  int
+	Win         bool
+	Draw        bool
+	DateTime    time.Time
 }
 
 type MatchResultRequest struct {
@@ -48,6 +49,127 @@ type MatchResultRequest struct {
 	MatchID     string
 }
 
+const (
+	MATCH_RESULT_TEAM_DRAW    = -1
+	MATCH_RESULT_TEAM_UNKNOWN = -2
+)
+
+// GetMatchResultWinnerTeamNumber converts a reported result into the number
+// of the team the reporter considers the winner.
+func GetMatchResultWinnerTeamNumber(matchResult *MatchResult, teamCount int) int {
+	if matchResult.Draw {
+		return MATCH_RESULT_TEAM_DRAW
+	}
+	if matchResult.Win {
+		return matchResult.TeamNumber
+	}
+	// A loss only identifies the winner when there is a single opponent team
+	if teamCount == 2 && matchResult.TeamNumber >= 0 && matchResult.TeamNumber < teamCount {
+		return 1 - matchResult.TeamNumber
+	}
+	return MATCH_RESULT_TEAM_UNKNOWN
+}
+
+// GetLastMatchResults keeps only the latest result reported by every user
+func GetLastMatchResults(matchState *MatchState) []*MatchResult {
+	var results []*MatchResult
+	index := make(map[string]int)
+	for _, matchResult := range matchState.Results {
+		if i, ok := index[matchResult.UserID]; ok {
+			results[i] = matchResult
+			continue
+		}
+		index[matchResult.UserID] = len(results)
+		results = append(results, matchResult)
+	}
+	return results
+}
+
+// TallyMatchResults counts the votes for every team and for a draw
+func TallyMatchResults(matchState *MatchState) []*TeamResult {
+	teamResults := []*TeamResult{&TeamResult{TeamNumber: MATCH_RESULT_TEAM_DRAW}}
+	for teamNumber := range matchState.Teams {
+		teamResults = append(teamResults, &TeamResult{TeamNumber: teamNumber})
+	}
+	for _, matchResult := range GetLastMatchResults(matchState) {
+		winner := GetMatchResultWinnerTeamNumber(matchResult, len(matchState.Teams))
+		if winner == MATCH_RESULT_TEAM_UNKNOWN || winner >= len(matchState.Teams) {
+			continue
+		}
+		teamResults[winner+1].Votes += 1
+	}
+	return teamResults
+}
+
+// GetMatchResultsByReporterTeam returns the outcome claimed by the majority
+// of the reporters of every team
+func GetMatchResultsByReporterTeam(matchState *MatchState) map[int]int {
+	votes := make(map[int]map[int]int)
+	for _, matchResult := range GetLastMatchResults(matchState) {
+		reporterTeam := GetTeamNumberFromUserAndMatch(matchResult.UserID, matchState)
+		winner := GetMatchResultWinnerTeamNumber(matchResult, len(matchState.Teams))
+		if reporterTeam == -1 || winner == MATCH_RESULT_TEAM_UNKNOWN {
+			continue
+		}
+		if _, ok := votes[reporterTeam]; !ok {
+			votes[reporterTeam] = make(map[int]int)
+		}
+		votes[reporterTeam][winner] += 1
+	}
+
+	outcomes := make(map[int]int)
+	for reporterTeam, teamVotes := range votes {
+		outcome, max := MATCH_RESULT_TEAM_UNKNOWN, 0
+		for winner, count := range teamVotes {
+			if count > max || (count == max && winner < outcome) {
+				outcome, max = winner, count
+			}
+		}
+		outcomes[reporterTeam] = outcome
+	}
+	return outcomes
+}
+
+// IsMatchResultDisputed reports whether the teams claim different outcomes
+func IsMatchResultDisputed(matchState *MatchState) bool {
+	outcome := MATCH_RESULT_TEAM_UNKNOWN
+	for _, winner := range GetMatchResultsByReporterTeam(matchState) {
+		if outcome == MATCH_RESULT_TEAM_UNKNOWN {
+			outcome = winner
+			continue
+		}
+		if outcome != winner {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMatchWinnerTeamNumber returns the winner agreed by the reported results
+// or, for the finalised matches, the team which received a positive reward
+func GetMatchWinnerTeamNumber(matchState *MatchState) int {
+	if !IsMatchResultDisputed(matchState) {
+		for _, winner := range GetMatchResultsByReporterTeam(matchState) {
+			return winner
+		}
+	}
+	for teamNumber, team := range matchState.Teams {
+		for _, teamUser := range team.TeamUsers {
+			if teamUser.Reward > 0 {
+				return teamNumber
+			}
+		}
+	}
+	return MATCH_RESULT_TEAM_UNKNOWN
+}
+
+func PrintTeamResults(teamResults []*TeamResult) string {
+	return ExecuteTemplate(
+		`> Votes:{{range $index, $element := .}} {{if lt .TeamNumber 0}}**Draw**{{else}}**Team {{.TeamNumber}}**{{end}}: {{.Votes}}{{end}}
+`,
+		teamResults)
+}
+
 func PrintMatchResults(matchState *MatchState) string {
 	return ExecuteTemplate(
 		`{{if .Results}}> Results: 
@@ -66,7 +188,7 @@ func createResult(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 	matchID, _ := cmd.Flags().GetString("matchID")
 	var matchState *MatchState
 	if len(args) == 0 {
-		matchState, err = getLastUserMatchState(cmdBuilder, account, MATCH_COLLECTION)
+		matchState, err = getLastUserSubmissionMatchState(cmdBuilder, account)
 	}
 
 	proof, _ := cmd.Flags().GetString("proof")
@@ -92,7 +214,7 @@ func createResult(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 
 	if matchID == "" {
 		if !draw && len(args) >= 2 {
-			matchState, err = getMatchState(cmdBuilder, args[1], MATCH_COLLECTION)
+			matchState, err = getMatchStateFromAnyCollection(cmdBuilder, args[1])
 			if err != nil {
 				log.Error(err)
 				return err
@@ -100,7 +222,7 @@ func createResult(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 		}
 
 		if draw && len(args) >= 1 {
-			matchState, err = getMatchState(cmdBuilder, args[0], MATCH_COLLECTION)
+			matchState, err = getMatchStateFromAnyCollection(cmdBuilder, args[0])
 			if err != nil {
 				log.Error(err)
 				return err
@@ -131,16 +253,36 @@ func createResult(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 		return fmt.Errorf("No match found for <@%v>", account.CustomId)
 	}
 
+	serverTime, err := getServerTime(cmdBuilder)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if err := ValidateSubmissionWindow(matchState, GetResultWindow(matchState), serverTime); err != nil {
+		return err
+	}
+
+	matchResult := &MatchResult{
+		DateTime:   serverTime,
+		UserID:     account.User.Id,
+		DiscordID:  account.CustomId,
+		TeamNumber: teamID,
+		Win:        win,
+		Draw:       draw,
+		ProofLink:  proof,
+	}
+	var proofRegisterRequest *ProofRegisterRequest
+	if proof != "" {
+		proofRegisterRequest, matchResult.ProofReused, err = checkProofReuse(cmdBuilder, cmd, account, matchState.MatchID, PROOF_USE_RESULT, proof, fetchProofLink(cmdBuilder, proof))
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+
 	payload, _ := json.Marshal(MatchResultRequest{
-		MatchResult: &MatchResult{
-			UserID:     account.User.Id,
-			DiscordID:  account.CustomId,
-			TeamNumber: teamID,
-			Win:        win,
-			Draw:       draw,
-			ProofLink:  proof,
-		},
-		MatchID: matchState.MatchID,
+		MatchResult: matchResult,
+		MatchID:     matchState.MatchID,
 	})
 	log.Infof("%+v\n", string(payload))
 
@@ -152,7 +294,14 @@ func createResult(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 	if result.Payload != "" {
 		fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result.Payload))
 	}
-	return nil
+	if proofRegisterRequest != nil {
+		if err := registerProof(cmdBuilder, proofRegisterRequest); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+
+	return checkMatchResultDispute(cmdBuilder, cmd, matchState.MatchID)
 }
 
 func setupResultFlags(cmd *cobra.Command, isDraw bool) {
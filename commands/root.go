@@ -20,10 +20,12 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 
 	"os"
 	"sync"
 
+	"github.com/bwmarrin/discordgo"
 	nakama "github.com/challenge-league/nakama-go/context"
 	"github.com/spf13/cobra"
 
@@ -38,8 +40,10 @@ var (
 )
 
 type commandsBuilder struct {
-	nakamaCtx *nakama.Context
-	rootCmd   *cobra.Command
+	nakamaCtx      *nakama.Context
+	rootCmd        *cobra.Command
+	proofVerifiers *ProofVerifierRegistry
+	embeds         []*discordgo.MessageEmbed
 }
 
 func NewCommandsBuilderSingleton() *commandsBuilder {
@@ -68,6 +72,32 @@ func (b *commandsBuilder) GetContext() *nakama.Context {
 	return b.nakamaCtx
 }
 
+func (b *commandsBuilder) SetProofVerifiers(proofVerifiers *ProofVerifierRegistry) *commandsBuilder {
+	b.proofVerifiers = proofVerifiers
+	return b
+}
+
+func (b *commandsBuilder) GetProofVerifiers() *ProofVerifierRegistry {
+	if b.proofVerifiers == nil {
+		b.proofVerifiers = NewProofVerifierRegistry(&http.Client{Timeout: PROOF_FETCH_TIMEOUT})
+	}
+	return b.proofVerifiers
+}
+
+// AddEmbed queues the embed for the bot to send along with the output of the
+// command
+func (b *commandsBuilder) AddEmbed(embed *discordgo.MessageEmbed) *commandsBuilder {
+	b.embeds = append(b.embeds, embed)
+	return b
+}
+
+// TakeEmbeds returns the queued embeds and clears them
+func (b *commandsBuilder) TakeEmbeds() []*discordgo.MessageEmbed {
+	embeds := b.embeds
+	b.embeds = nil
+	return embeds
+}
+
 func (b *commandsBuilder) SetRootCmd(rootCmd *cobra.Command) *commandsBuilder {
 	b.rootCmd = rootCmd
 	return b
@@ -123,7 +153,6 @@ func (b *commandsBuilder) SetCommandsAndFlags() *commandsBuilder {
 	*/
 
 	cmdGet := getCmdGet(b)
-	//cmdGet.AddCommand(getCmdTournamentGet(b))
 	//cmdGet.AddCommand(getCmdGroupGet(b))
 	//cmdGet.AddCommand(getCmdGroupUsersGet(b))
 	cmdGet.AddCommand(getCmdMatchGet(b))
@@ -131,8 +160,6 @@ func (b *commandsBuilder) SetCommandsAndFlags() *commandsBuilder {
 	cmdGet.AddCommand(getCmdTopLeaderboardRecordsGet(b))
 	//cmdGet.AddCommand(getCmdLeaderboardRecordAroundOwnerGet(b))
 	cmdGet.AddCommand(getCmdTicketGet(b))
-	//cmdGet.AddCommand(getCmdTournamentRecordGet(b))
-	//cmdGet.AddCommand(getCmdTournamentRecordAroundOwnerGet(b))
 	cmdGet.AddCommand(getCmdUserGet(b))
 	//cmdGet.AddCommand(getCmdUserGroupsGet(b))
 	b.rootCmd.AddCommand(cmdGet)
@@ -153,6 +180,9 @@ func (b *commandsBuilder) SetCommandsAndFlags() *commandsBuilder {
 	cmdSubmit := getCmdSubmit(b)
 	b.rootCmd.AddCommand(cmdSubmit)
 
+	cmdSubmissions := getCmdSubmissions(b)
+	b.rootCmd.AddCommand(cmdSubmissions)
+
 	cmdJoinMatchPool := getCmdJoinMatchPool(b)
 	b.rootCmd.AddCommand(cmdJoinMatchPool)
 
@@ -162,6 +192,9 @@ func (b *commandsBuilder) SetCommandsAndFlags() *commandsBuilder {
 	cmdAddUserToMatchPool := getCmdAddUserToMatchPool(b)
 	b.rootCmd.AddCommand(cmdAddUserToMatchPool)
 
+	cmdTeamTrade := getCmdTeamTrade(b)
+	b.rootCmd.AddCommand(cmdTeamTrade)
+
 	/*
 
 		cmdLeave := getCmdLeave(b)
@@ -198,6 +231,72 @@ func (b *commandsBuilder) SetCommandsAndFlags() *commandsBuilder {
 	cmdChallengeCreate := getCmdCaptainsDraftCreate(b)
 	b.rootCmd.AddCommand(cmdChallengeCreate)
 
+	cmdMatchHistory := getCmdMatchHistory(b)
+	b.rootCmd.AddCommand(cmdMatchHistory)
+
+	cmdUserProfile := getCmdUserProfile(b)
+	b.rootCmd.AddCommand(cmdUserProfile)
+
+	cmdHeadToHead := getCmdHeadToHead(b)
+	b.rootCmd.AddCommand(cmdHeadToHead)
+
+	cmdRematch := getCmdRematch(b)
+	b.rootCmd.AddCommand(cmdRematch)
+
+	cmdSubstitute := getCmdSubstitute(b)
+	b.rootCmd.AddCommand(cmdSubstitute)
+
+	cmdSchedule := getCmdSchedule(b)
+	b.rootCmd.AddCommand(cmdSchedule)
+
+	cmdCalendar := getCmdCalendar(b)
+	b.rootCmd.AddCommand(cmdCalendar)
+
+	cmdMatchDurationExtend := getCmdMatchDurationExtend(b)
+	b.rootCmd.AddCommand(cmdMatchDurationExtend)
+
+	cmdTournament := getCmdTournament(b)
+	if checkPermission(b) {
+		cmdTournament.AddCommand(getCmdTournamentCreate(b))
+		cmdTournament.AddCommand(getCmdTournamentDelete(b))
+		cmdTournament.AddCommand(getCmdTournamentRegistration(b))
+	}
+	b.rootCmd.AddCommand(cmdTournament)
+
+	cmdBracket := getCmdBracket(b)
+	if checkPermission(b) {
+		cmdBracket.AddCommand(getCmdBracketCreate(b))
+		cmdBracket.AddCommand(getCmdBracketDecide(b))
+	}
+	b.rootCmd.AddCommand(cmdBracket)
+
+	cmdPairing := getCmdPairing(b)
+	if checkPermission(b) {
+		cmdPairing.AddCommand(getCmdPairingCreate(b))
+	}
+	b.rootCmd.AddCommand(cmdPairing)
+
+	cmdSeason := getCmdSeason(b)
+	if checkPermission(b) {
+		cmdSeason.AddCommand(getCmdSeasonCreate(b))
+		cmdSeason.AddCommand(getCmdSeasonArchive(b))
+	}
+	b.rootCmd.AddCommand(cmdSeason)
+
+	if checkPermission(b) {
+		cmdExport := getCmdExport(b)
+		b.rootCmd.AddCommand(cmdExport)
+
+		cmdMatchDisputeResolve := getCmdMatchDisputeResolve(b)
+		b.rootCmd.AddCommand(cmdMatchDisputeResolve)
+
+		cmdAdmin := getCmdAdmin(b)
+		cmdAdmin.AddCommand(getCmdAdminMatch(b))
+		cmdAdmin.AddCommand(getCmdAdminAuditGet(b))
+		cmdAdmin.AddCommand(getCmdAdminProofReport(b))
+		b.rootCmd.AddCommand(cmdAdmin)
+	}
+
 	/*
 		cmdWatch := getCmdWatch(b)
 		cmdWatch.AddCommand(getCmdTicketWatchAssignments(b))
@@ -245,6 +344,14 @@ func ExecuteCommandC(b *commandsBuilder, args ...string) (output string, err err
 	return string(out), err
 }
 
+// ExecuteCommandEmbedsC executes the command like ExecuteCommandC and returns
+// the embeds it has made for the bot to send
+func ExecuteCommandEmbedsC(b *commandsBuilder, args ...string) (string, []*discordgo.MessageEmbed, error) {
+	b.TakeEmbeds()
+	output, err := ExecuteCommandC(b, args...)
+	return output, b.TakeEmbeds(), err
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute(b *commandsBuilder) {
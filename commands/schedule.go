@@ -0,0 +1,240 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	SCHEDULE_TIME_LAYOUT   = "2006-01-02 15:04"
+	SCHEDULE_DEFAULT_TZ    = "UTC"
+	SCHEDULE_MIN_LEAD_TIME = 15 * time.Minute
+	SCHEDULE_MAX_LEAD_TIME = 30 * 24 * time.Hour
+
+	MATCH_EXTENSION_DATE_TIME_SCHEDULED  = "date_time_scheduled"
+	MATCH_EXTENSION_SCHEDULE_REMINDERS   = "schedule_reminders"
+	MATCH_EXTENSION_SCHEDULED_TEAM_USERS = "scheduled_team_users"
+)
+
+// MatchScheduleRequest makes the server open the scheduled match at its
+// MATCH_EXTENSION_DATE_TIME_SCHEDULED. Then the server writes the ticket
+// states of the players from the tickets of the match and moves the match to
+// awaiting users ready, the match is cancelled if a player has another ticket.
+type MatchScheduleRequest struct {
+	MatchID string
+}
+
+// MatchScheduleCancelRequest drops the opening of the cancelled match
+type MatchScheduleCancelRequest struct {
+	MatchID string
+}
+
+// SCHEDULE_REMINDERS are the offsets before the scheduled time at which the
+// server reminds the participants of the match
+var SCHEDULE_REMINDERS = []time.Duration{24 * time.Hour, time.Hour, 15 * time.Minute}
+
+// parseDateTimeFlag parses the date and time of the flag in the time zone
+// of the --tz flag
+func parseDateTimeFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	tz, _ := cmd.Flags().GetString("tz")
+	location, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%v' is not a valid time zone, expected IANA name like UTC or Europe/Berlin", tz)
+	}
+	dateTime, err := time.ParseInLocation(SCHEDULE_TIME_LAYOUT, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%v' is not a valid date and time, expected format: %v", value, SCHEDULE_TIME_LAYOUT)
+	}
+	return dateTime.UTC(), nil
+}
+
+func parseScheduleFlags(cmd *cobra.Command) (time.Time, error) {
+	dateTime, err := parseDateTimeFlag(cmd, "at")
+	if err != nil || dateTime.IsZero() {
+		return dateTime, err
+	}
+
+	leadTime := time.Until(dateTime)
+	if leadTime < SCHEDULE_MIN_LEAD_TIME {
+		return time.Time{}, fmt.Errorf("The match can be scheduled no earlier than %v from now", formatDuraiton(SCHEDULE_MIN_LEAD_TIME))
+	}
+	if leadTime > SCHEDULE_MAX_LEAD_TIME {
+		return time.Time{}, fmt.Errorf("The match can be scheduled no later than %v from now", formatDuraiton(SCHEDULE_MAX_LEAD_TIME))
+	}
+	return dateTime, nil
+}
+
+// NewScheduledTeamUsers lists the players of the scheduled match before its
+// teams are built, DateTimeJoin is the time the match was scheduled
+func NewScheduledTeamUsers(ticketStates []*TicketState, dateTime time.Time) ([]*TeamUser, error) {
+	var teamUsers []*TeamUser
+	for _, ticketState := range ticketStates {
+		var teamUser *TeamUser
+		if err := json.Unmarshal(ticketState.Ticket.Extensions[TICKET_EXTENSION_USER].Value, &teamUser); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		teamUser.TicketID = ticketState.Ticket.Id
+		teamUser.DateTimeJoin = dateTime
+		teamUsers = append(teamUsers, teamUser)
+	}
+	return teamUsers, nil
+}
+
+// GetScheduleReminders returns the reminders which are still ahead of the
+// scheduled time
+func GetScheduleReminders(dateTimeScheduled time.Time) []time.Duration {
+	var reminders []time.Duration
+	leadTime := time.Until(dateTimeScheduled)
+	for _, reminder := range SCHEDULE_REMINDERS {
+		if reminder < leadTime {
+			reminders = append(reminders, reminder)
+		}
+	}
+	return reminders
+}
+
+func IsUserIDInScheduledMatch(userID string, matchState *MatchState) bool {
+	return IsUserIDInMatch(userID, matchState) ||
+		IsStringInSlice(userID, matchState.CaptainUserIDs) ||
+		IsStringInSlice(userID, matchState.PoolUserIDs)
+}
+
+func PrintScheduledMatch(matchState *MatchState) string {
+	return ExecuteTemplate(
+		`> `+"`{{.MatchID}}`"+` **{{.DateTimeScheduled | formatTimeAsDate}}** **{{.MatchProfile}}** {{.Duration | formatDuration}}{{range .CaptainUserIDs}} <@{{.}}>{{end}}
+`,
+		matchState)
+}
+
+func getScheduledMatchStateList(cmdBuilder *commandsBuilder, userID string) ([]*MatchState, error) {
+	matchStateList, err := getMatchStateList(cmdBuilder, MATCH_COLLECTION)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var scheduledMatchStateList []*MatchState
+	for _, matchState := range matchStateList {
+		if matchState.Status == MATCH_STATUS_SCHEDULED && IsUserIDInScheduledMatch(userID, matchState) {
+			scheduledMatchStateList = append(scheduledMatchStateList, matchState)
+		}
+	}
+	sort.Slice(scheduledMatchStateList, func(i, j int) bool {
+		return scheduledMatchStateList[i].DateTimeScheduled.Before(scheduledMatchStateList[j].DateTimeScheduled)
+	})
+	return scheduledMatchStateList, nil
+}
+
+// cancelScheduledMatch cancels the match which has no tickets yet, its opening
+// is dropped only once the match is cancelled
+func cancelScheduledMatch(cmdBuilder *commandsBuilder, cmd *cobra.Command, account *api.Account, matchID string) error {
+	matchState, err := getMatchState(cmdBuilder, matchID, MATCH_COLLECTION)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if matchState == nil || matchState.Status != MATCH_STATUS_SCHEDULED || !IsUserIDInScheduledMatch(account.User.Id, matchState) {
+		return fmt.Errorf("No scheduled match **%v** found for <@%v>", matchID, account.CustomId)
+	}
+
+	payload, _ := json.Marshal(MatchCancelRequest{
+		MatchID: matchState.MatchID,
+		UserID:  account.User.Id,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchCancel", Payload: string(payload)})
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if matchState, err = getMatchState(cmdBuilder, matchID, MATCH_COLLECTION); err != nil {
+		log.Error(err)
+		return err
+	}
+	if matchState != nil && matchState.Status == MATCH_STATUS_CANCELED {
+		payload, _ := json.Marshal(&MatchScheduleCancelRequest{
+			MatchID: matchState.MatchID,
+		})
+		log.Infof("%+v\n", string(payload))
+
+		if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchScheduleCancel", Payload: string(payload)}); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	if result.Payload != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result.Payload))
+	}
+	return nil
+}
+
+func getCmdSchedule(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "schedule [user]",
+		Aliases: []string{"sched", "upcoming"},
+		Short:   "List the **upcoming scheduled matches** of a user",
+		Long: `List the **upcoming scheduled matches** of a user
+Schedule a match with **dl challenge @user --at "2006-01-02 15:04" --tz UTC**
+Cancel a scheduled match with **dl cancel --matchID <matchID>**`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(args) > 0 {
+				if account, err = getAccount(cmdBuilder, args[0]); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			matchStateList, err := getScheduledMatchStateList(cmdBuilder, account.User.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(matchStateList) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("No scheduled matches found for <@%v>", account.CustomId))
+				return nil
+			}
+
+			msg := fmt.Sprintf("> Upcoming matches of <@%v>:\n", account.CustomId)
+			for _, matchState := range matchStateList {
+				msg += PrintScheduledMatch(matchState)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	return cmd
+}
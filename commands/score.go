@@ -0,0 +1,249 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+)
+
+const (
+	DEFAULT_SCORE_PRECISION = 4
+	MAX_SCORE_PRECISION     = 18
+	// LEGACY_SCORE_PRECISION is the "%.10f" subscore of the submits written
+	// before the codec
+	LEGACY_SCORE_PRECISION = 10
+
+	LEADERBOARD_METADATA_SCORE_PRECISION       = "score_precision"
+	LEADERBOARD_METADATA_SCORE_LOWER_IS_BETTER = "score_lower_is_better"
+)
+
+// ScoreCodec maps a decimal score to the Nakama Score/Subscore pair without
+// floating point: Score is the integer part rounded down and Subscore is the
+// fraction scaled by 10^Precision, so the pairs sort in the order of the
+// values, negative scores included. Lower-is-better leaderboards are created
+// with the "asc" sort order, the codec only uses LowerIsBetter to compare.
+type ScoreCodec struct {
+	Precision     int
+	LowerIsBetter bool
+}
+
+var (
+	DEFAULT_SCORE_CODEC = &ScoreCodec{Precision: DEFAULT_SCORE_PRECISION}
+	LEGACY_SCORE_CODEC  = &ScoreCodec{Precision: LEGACY_SCORE_PRECISION}
+
+	loadedScoreCodecs sync.Map
+)
+
+type LeaderboardGetRequest struct {
+	ID string
+}
+
+// Leaderboard is the leaderboard returned by the LeaderboardGet RPC
+type Leaderboard struct {
+	ID        string
+	SortOrder string
+	Operator  string
+	Metadata  map[string]interface{}
+}
+
+func NewScoreCodec(precision int, lowerIsBetter bool) (*ScoreCodec, error) {
+	if precision < 0 || precision > MAX_SCORE_PRECISION {
+		return nil, fmt.Errorf("score precision must be between 0 and %v", MAX_SCORE_PRECISION)
+	}
+	return &ScoreCodec{Precision: precision, LowerIsBetter: lowerIsBetter}, nil
+}
+
+// NewScoreCodecFromMetadata reads the codec written by Metadata. The
+// leaderboards created before the codec have no score metadata, they keep
+// the legacy encoding so that their old and new records still compare.
+func NewScoreCodecFromMetadata(metadata map[string]interface{}) *ScoreCodec {
+	precision, ok := metadata[LEADERBOARD_METADATA_SCORE_PRECISION].(float64)
+	if !ok {
+		return LEGACY_SCORE_CODEC
+	}
+	lowerIsBetter, _ := metadata[LEADERBOARD_METADATA_SCORE_LOWER_IS_BETTER].(bool)
+	return &ScoreCodec{Precision: int(precision), LowerIsBetter: lowerIsBetter}
+}
+
+// getScoreCodec loads the codec from the metadata of the leaderboard once
+func getScoreCodec(cmdBuilder *commandsBuilder, leaderboardID string) (*ScoreCodec, error) {
+	if codec, ok := loadedScoreCodecs.Load(leaderboardID); ok {
+		return codec.(*ScoreCodec), nil
+	}
+	payload, _ := json.Marshal(&LeaderboardGetRequest{
+		ID: leaderboardID,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "LeaderboardGet", Payload: string(payload)})
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var leaderboard *Leaderboard
+	if err := json.Unmarshal([]byte(result.Payload), &leaderboard); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if leaderboard == nil {
+		return nil, fmt.Errorf("No leaderboard found for **%v**", leaderboardID)
+	}
+	codec := NewScoreCodecFromMetadata(leaderboard.Metadata)
+	loadedScoreCodecs.Store(leaderboardID, codec)
+	return codec, nil
+}
+
+func getTournamentScoreCodec(tournament *api.Tournament) (*ScoreCodec, error) {
+	var metadata map[string]interface{}
+	if tournament.Metadata != "" {
+		if err := json.Unmarshal([]byte(tournament.Metadata), &metadata); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+	}
+	return NewScoreCodecFromMetadata(metadata), nil
+}
+
+// getMatchScoreCodec returns the codec of the match or of the main
+// leaderboard, the routed leaderboards share its codec
+func getMatchScoreCodec(cmdBuilder *commandsBuilder, matchState *MatchState) (*ScoreCodec, error) {
+	if matchState.ScoreCodec != nil {
+		return matchState.ScoreCodec, nil
+	}
+	return getScoreCodec(cmdBuilder, MAIN_LEADERBOARD)
+}
+
+func (c *ScoreCodec) scale() int64 {
+	scale := int64(1)
+	for i := 0; i < c.Precision; i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// Encode parses the decimal string exactly, the values with more fraction
+// digits than the precision are refused rather than rounded
+func (c *ScoreCodec) Encode(value string) (int64, int64, error) {
+	text := strings.TrimSpace(value)
+	negative := false
+	switch {
+	case strings.HasPrefix(text, "-"):
+		negative = true
+		text = text[1:]
+	case strings.HasPrefix(text, "+"):
+		text = text[1:]
+	}
+
+	integerPart, fractionPart := text, ""
+	if i := strings.Index(text, "."); i != -1 {
+		integerPart, fractionPart = text[:i], text[i+1:]
+	}
+	if integerPart == "" && fractionPart == "" || !isDigits(integerPart) || !isDigits(fractionPart) {
+		return 0, 0, fmt.Errorf("'%v' is not a valid decimal score", value)
+	}
+	fractionPart = strings.TrimRight(fractionPart, "0")
+	if len(fractionPart) > c.Precision {
+		return 0, 0, fmt.Errorf("'%v' has more than %v digits after the decimal point", value, c.Precision)
+	}
+
+	score := int64(0)
+	if integerPart != "" {
+		var err error
+		if score, err = strconv.ParseInt(integerPart, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("'%v' is out of the score range", value)
+		}
+	}
+	subscore := int64(0)
+	if fractionPart != "" {
+		fraction, _ := strconv.ParseInt(fractionPart+strings.Repeat("0", c.Precision-len(fractionPart)), 10, 64)
+		subscore = fraction
+	}
+
+	if negative {
+		score = -score
+		if subscore != 0 {
+			score -= 1
+			subscore = c.scale() - subscore
+		}
+	}
+	return score, subscore, nil
+}
+
+// Decode renders the pair back with exactly Precision fraction digits
+func (c *ScoreCodec) Decode(score int64, subscore int64) string {
+	sign := ""
+	integerPart := score
+	fraction := subscore
+	if score < 0 {
+		sign = "-"
+		integerPart = -score
+		if subscore != 0 {
+			integerPart -= 1
+			fraction = c.scale() - subscore
+		}
+	}
+	if c.Precision == 0 {
+		return fmt.Sprintf("%v%v", sign, integerPart)
+	}
+	return fmt.Sprintf("%v%v.%0*d", sign, integerPart, c.Precision, fraction)
+}
+
+// Compare returns a positive number if the first pair is the better score
+func (c *ScoreCodec) Compare(score int64, subscore int64, otherScore int64, otherSubscore int64) int {
+	result := 0
+	switch {
+	case score > otherScore:
+		result = 1
+	case score < otherScore:
+		result = -1
+	case subscore > otherSubscore:
+		result = 1
+	case subscore < otherSubscore:
+		result = -1
+	}
+	if c.LowerIsBetter {
+		return -result
+	}
+	return result
+}
+
+// Float is only meant for the aggregates like the average score
+func (c *ScoreCodec) Float(score int64, subscore int64) float64 {
+	return float64(score) + float64(subscore)/float64(c.scale())
+}
+
+func (c *ScoreCodec) Metadata() map[string]interface{} {
+	return map[string]interface{}{
+		LEADERBOARD_METADATA_SCORE_PRECISION:       c.Precision,
+		LEADERBOARD_METADATA_SCORE_LOWER_IS_BETTER: c.LowerIsBetter,
+	}
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"testing"
+)
+
+func TestScoreCodecEncode(t *testing.T) {
+	codec, _ := NewScoreCodec(DEFAULT_SCORE_PRECISION, false)
+	for _, test := range []struct {
+		value    string
+		score    int64
+		subscore int64
+		decoded  string
+	}{
+		{"0.05", 0, 500, "0.0500"},
+		{"0.5", 0, 5000, "0.5000"},
+		{"1.50", 1, 5000, "1.5000"},
+		{"1.5000", 1, 5000, "1.5000"},
+		{"0.00010", 0, 1, "0.0001"},
+		{"12", 12, 0, "12.0000"},
+		{"+3.25", 3, 2500, "3.2500"},
+		{" 7 ", 7, 0, "7.0000"},
+		{".25", 0, 2500, "0.2500"},
+		{"-0.5", -1, 5000, "-0.5000"},
+		{"-.5", -1, 5000, "-0.5000"},
+		{"-0", 0, 0, "0.0000"},
+		{"-0.0", 0, 0, "0.0000"},
+		{"-1", -1, 0, "-1.0000"},
+		{"-1.25", -2, 7500, "-1.2500"},
+		{"-0.0001", -1, 9999, "-0.0001"},
+	} {
+		t.Run(test.value, func(t *testing.T) {
+			score, subscore, err := codec.Encode(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if score != test.score || subscore != test.subscore {
+				t.Errorf("(%v, %v), want (%v, %v)", score, subscore, test.score, test.subscore)
+			}
+			if decoded := codec.Decode(score, subscore); decoded != test.decoded {
+				t.Errorf("decoded %v, want %v", decoded, test.decoded)
+			}
+		})
+	}
+}
+
+func TestScoreCodecEncodeInvalid(t *testing.T) {
+	codec, _ := NewScoreCodec(DEFAULT_SCORE_PRECISION, false)
+	for _, value := range []string{
+		"",
+		"-",
+		".",
+		"abc",
+		"1.2.3",
+		"1e3",
+		"--1",
+		"0.00001",
+		"-0.12345",
+		"99999999999999999999",
+	} {
+		t.Run(value, func(t *testing.T) {
+			if score, subscore, err := codec.Encode(value); err == nil {
+				t.Errorf("(%v, %v), want an error", score, subscore)
+			}
+		})
+	}
+}
+
+func TestNewScoreCodecPrecision(t *testing.T) {
+	for _, test := range []struct {
+		precision int
+		valid     bool
+	}{
+		{-1, false},
+		{0, true},
+		{DEFAULT_SCORE_PRECISION, true},
+		{MAX_SCORE_PRECISION, true},
+		{MAX_SCORE_PRECISION + 1, false},
+	} {
+		if _, err := NewScoreCodec(test.precision, false); (err == nil) != test.valid {
+			t.Errorf("precision %v: error %v, want valid %v", test.precision, err, test.valid)
+		}
+	}
+}
+
+func TestScoreCodecMaxPrecision(t *testing.T) {
+	codec, _ := NewScoreCodec(MAX_SCORE_PRECISION, false)
+	for _, test := range []struct {
+		value    string
+		score    int64
+		subscore int64
+	}{
+		{"0.000000000000000001", 0, 1},
+		{"0.999999999999999999", 0, 999999999999999999},
+		{"-0.000000000000000001", -1, 999999999999999999},
+	} {
+		score, subscore, err := codec.Encode(test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score != test.score || subscore != test.subscore {
+			t.Errorf("%v: (%v, %v), want (%v, %v)", test.value, score, subscore, test.score, test.subscore)
+		}
+		if decoded := codec.Decode(score, subscore); decoded != test.value {
+			t.Errorf("decoded %v, want %v", decoded, test.value)
+		}
+	}
+	if _, _, err := codec.Encode("0.0000000000000000001"); err == nil {
+		t.Errorf("want an error for 19 digits after the decimal point")
+	}
+
+	codec, _ = NewScoreCodec(0, false)
+	if score, subscore, _ := codec.Encode("-3"); codec.Decode(score, subscore) != "-3" {
+		t.Errorf("decoded %v with no fraction digits, want -3", codec.Decode(score, subscore))
+	}
+	if _, _, err := codec.Encode("3.5"); err == nil {
+		t.Errorf("want an error for a fraction with no fraction digits")
+	}
+}
+
+func TestScoreCodecRoundTrip(t *testing.T) {
+	codec, _ := NewScoreCodec(DEFAULT_SCORE_PRECISION, false)
+	for _, value := range []string{"0.0000", "0.0500", "0.5000", "-0.5000", "-1.0000", "-1.2500", "123456.7890", "-123456.7890"} {
+		score, subscore, err := codec.Encode(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded := codec.Decode(score, subscore); decoded != value {
+			t.Errorf("Decode(Encode(%v)) = %v", value, decoded)
+		}
+	}
+}
+
+func TestScoreCodecOrder(t *testing.T) {
+	// ascending values across zero
+	values := []string{"-10", "-1.5", "-1", "-0.5", "-0.05", "-0.0001", "0", "0.0001", "0.05", "0.5", "1", "1.5", "10"}
+	for _, lowerIsBetter := range []bool{false, true} {
+		codec, _ := NewScoreCodec(DEFAULT_SCORE_PRECISION, lowerIsBetter)
+		for i := 0; i+1 < len(values); i++ {
+			score, subscore, _ := codec.Encode(values[i])
+			nextScore, nextSubscore, _ := codec.Encode(values[i+1])
+			if score > nextScore || score == nextScore && subscore >= nextSubscore {
+				t.Errorf("(%v, %v) of %v does not sort before (%v, %v) of %v", score, subscore, values[i], nextScore, nextSubscore, values[i+1])
+			}
+			compare := codec.Compare(score, subscore, nextScore, nextSubscore)
+			if lowerIsBetter && compare <= 0 || !lowerIsBetter && compare >= 0 {
+				t.Errorf("Compare(%v, %v) = %v with lowerIsBetter %v", values[i], values[i+1], compare, lowerIsBetter)
+			}
+		}
+	}
+}
@@ -0,0 +1,529 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/challenge-league/nakama-go/context"
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	SEASON_COLLECTION         = "season_data"
+	SEASON_ARCHIVE_COLLECTION = "season_archive_data"
+	SEASON_LEADERBOARD_PREFIX = "season_"
+
+	// the match results are recorded on the leaderboard of the running season
+	// as well as on the leaderboard the match is routed to
+	MATCH_EXTENSION_SEASON_LEADERBOARD_ID  = "season_leaderboard_id"
+	TICKET_EXTENSION_SEASON_LEADERBOARD_ID = "season_leaderboard_id"
+
+	DEFAULT_SEASON_STANDINGS_COUNT = 10
+)
+
+var seasonIDRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Season is backed by its own leaderboard which is reset by ResetSchedule at
+// the rollover, the final standings are kept in SEASON_ARCHIVE_COLLECTION by
+// the server at DateTimeEnd, the next reset comes only after it
+type Season struct {
+	ID            string
+	LeaderboardID string
+	DateTimeStart time.Time
+	DateTimeEnd   time.Time
+	ResetSchedule string
+	Archived      bool
+}
+
+type SeasonStanding struct {
+	Rank      int64
+	UserID    string
+	DiscordID string
+	Score     int64
+	Subscore  int64
+}
+
+type SeasonArchive struct {
+	Season           *Season
+	Standings        []*SeasonStanding
+	Champion         *SeasonStanding
+	DateTimeArchived time.Time
+}
+
+// SeasonCreateRequest makes the server snapshot the final standings of the
+// season at its DateTimeEnd
+type SeasonCreateRequest struct {
+	Season *Season
+}
+
+// SeasonArchiveCreateRequest stores the archive and marks the season archived,
+// the server never overwrites an existing archive
+type SeasonArchiveCreateRequest struct {
+	SeasonArchive *SeasonArchive
+}
+
+func (s *Season) IsCurrent(now time.Time) bool {
+	return !now.Before(s.DateTimeStart) && now.Before(s.DateTimeEnd)
+}
+
+func (s *Season) Overlaps(other *Season) bool {
+	return s.DateTimeStart.Before(other.DateTimeEnd) && other.DateTimeStart.Before(s.DateTimeEnd)
+}
+
+// validateSeasonResetSchedule makes sure the standings are archived at the
+// end of the season before the leaderboard is reset
+func validateSeasonResetSchedule(season *Season, now time.Time) error {
+	if season.ResetSchedule == "" {
+		return nil
+	}
+	dateTimeReset, err := GetCronNextTime(season.ResetSchedule, now)
+	if err != nil {
+		return err
+	}
+	if !dateTimeReset.After(season.DateTimeEnd) {
+		return fmt.Errorf("The leaderboard would be reset at %v before the season ends at %v, the reset must come after the end",
+			formatTimeAsDate(dateTimeReset), formatTimeAsDate(season.DateTimeEnd))
+	}
+	return nil
+}
+
+func NewSeasonArchive(season *Season, leaderboardRecords []*api.LeaderboardRecord) *SeasonArchive {
+	seasonArchive := &SeasonArchive{
+		Season:           season,
+		DateTimeArchived: time.Now().UTC(),
+	}
+	for _, record := range leaderboardRecords {
+		seasonArchive.Standings = append(seasonArchive.Standings, &SeasonStanding{
+			Rank:      record.Rank,
+			UserID:    record.OwnerId,
+			DiscordID: record.Username.GetValue(),
+			Score:     record.Score,
+			Subscore:  record.Subscore,
+		})
+	}
+	if len(seasonArchive.Standings) > 0 {
+		seasonArchive.Champion = seasonArchive.Standings[0]
+	}
+	return seasonArchive
+}
+
+func PrintSeason(season *Season, seasonArchive *SeasonArchive) string {
+	msg := ExecuteTemplate(
+		`> **{{.ID}}** {{.DateTimeStart | formatTimeAsDate}} - {{.DateTimeEnd | formatTimeAsDate}}{{if .ResetSchedule}} reset `+"`{{.ResetSchedule}}`"+`{{end}}`,
+		season)
+	if seasonArchive != nil && seasonArchive.Champion != nil {
+		msg += fmt.Sprintf(" champion <@%v>", seasonArchive.Champion.DiscordID)
+	}
+	return msg + "\n"
+}
+
+func PrintSeasonStandings(season *Season, standings []*SeasonStanding, codec *ScoreCodec) string {
+	msg := fmt.Sprintf("> Season **%v** standings:\n", season.ID)
+	for _, standing := range standings {
+		msg += fmt.Sprintf("> **#%v** <@%v> %v\n", standing.Rank, standing.DiscordID, codec.Decode(standing.Score, standing.Subscore))
+	}
+	return msg
+}
+
+func getSeasonList(cmdBuilder *commandsBuilder) ([]*Season, error) {
+	objects, err := listUserStorageObjects(cmdBuilder, SEASON_COLLECTION, context.NakamaSystemUserID, "")
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var seasons []*Season
+	for _, object := range objects {
+		var season *Season
+		if err := json.Unmarshal([]byte(object.Value), &season); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		seasons = append(seasons, season)
+	}
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].DateTimeStart.After(seasons[j].DateTimeStart)
+	})
+	return seasons, nil
+}
+
+func getSeason(cmdBuilder *commandsBuilder, seasonID string) (*Season, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, SEASON_COLLECTION, seasonID, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if len(storageObjects) == 0 {
+		return nil, fmt.Errorf("Season **%v** not found", seasonID)
+	}
+	var season *Season
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &season); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return season, nil
+}
+
+func getSeasonArchive(cmdBuilder *commandsBuilder, seasonID string) (*SeasonArchive, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, SEASON_ARCHIVE_COLLECTION, seasonID, context.NakamaSystemUserID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if len(storageObjects) == 0 {
+		return nil, nil
+	}
+	var seasonArchive *SeasonArchive
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &seasonArchive); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return seasonArchive, nil
+}
+
+func getCurrentSeason(seasons []*Season) *Season {
+	now := time.Now().UTC()
+	for _, season := range seasons {
+		if season.IsCurrent(now) {
+			return season
+		}
+	}
+	return nil
+}
+
+// getCurrentSeasonLeaderboardID returns an empty ID if no season is running
+func getCurrentSeasonLeaderboardID(cmdBuilder *commandsBuilder) (string, error) {
+	seasons, err := getSeasonList(cmdBuilder)
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+	if season := getCurrentSeason(seasons); season != nil {
+		return season.LeaderboardID, nil
+	}
+	return "", nil
+}
+
+// archiveSeason snapshots the final standings of the ended season which the
+// server has failed to archive, as long as its leaderboard is not reset
+func archiveSeason(cmdBuilder *commandsBuilder, season *Season) (*SeasonArchive, error) {
+	now := time.Now().UTC()
+	if season.Archived {
+		return nil, fmt.Errorf("The season **%v** is already archived", season.ID)
+	}
+	if now.Before(season.DateTimeEnd) {
+		return nil, fmt.Errorf("The season **%v** is archived by the server when it ends at %v", season.ID, formatTimeAsDate(season.DateTimeEnd))
+	}
+	if season.ResetSchedule != "" {
+		dateTimeReset, err := GetCronNextTime(season.ResetSchedule, season.DateTimeEnd)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		if !now.Before(dateTimeReset) {
+			return nil, fmt.Errorf("The leaderboard of the season **%v** was reset at %v, its standings can not be archived anymore", season.ID, formatTimeAsDate(dateTimeReset))
+		}
+	}
+	existingArchive, err := getSeasonArchive(cmdBuilder, season.ID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if existingArchive != nil && len(existingArchive.Standings) > 0 {
+		return nil, fmt.Errorf("The season **%v** is already archived", season.ID)
+	}
+
+	leaderboardRecords, err := getLeaderboardRecordList(cmdBuilder, &api.ListLeaderboardRecordsRequest{
+		LeaderboardId: season.LeaderboardID,
+		Limit:         &wrapperspb.Int32Value{Value: MAX_LIST_LIMIT},
+	})
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	seasonArchive := NewSeasonArchive(season, leaderboardRecords)
+	seasonArchive.Season.Archived = true
+
+	payload, _ := json.Marshal(&SeasonArchiveCreateRequest{SeasonArchive: seasonArchive})
+	log.Infof("%+v\n", string(payload))
+
+	if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "SeasonArchiveCreate", Payload: string(payload)}); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return seasonArchive, nil
+}
+
+func getSeasonStandings(cmdBuilder *commandsBuilder, season *Season, count int) ([]*SeasonStanding, error) {
+	if season.Archived {
+		seasonArchive, err := getSeasonArchive(cmdBuilder, season.ID)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		if seasonArchive != nil {
+			standings := seasonArchive.Standings
+			if count > 0 && len(standings) > count {
+				standings = standings[:count]
+			}
+			return standings, nil
+		}
+	}
+
+	result, err := cmdBuilder.nakamaCtx.Client.ListLeaderboardRecords(cmdBuilder.nakamaCtx.Ctx, &api.ListLeaderboardRecordsRequest{
+		LeaderboardId: season.LeaderboardID,
+		Limit:         &wrapperspb.Int32Value{Value: int32(count)},
+	})
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return NewSeasonArchive(season, result.GetRecords()).Standings, nil
+}
+
+func getCmdSeasonList(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the **seasons** with the champions of the finished ones",
+		Long:  `List the **seasons** with the champions of the finished ones`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			seasons, err := getSeasonList(cmdBuilder)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(seasons) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No seasons found")
+				return nil
+			}
+			msg := "> Seasons:\n"
+			for _, season := range seasons {
+				var seasonArchive *SeasonArchive
+				if season.Archived {
+					if seasonArchive, err = getSeasonArchive(cmdBuilder, season.ID); err != nil {
+						log.Error(err)
+						return err
+					}
+				}
+				msg += PrintSeason(season, seasonArchive)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func getCmdSeasonCurrent(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "current",
+		Short: "Get the **current season** and its standings",
+		Long:  `Get the **current season** and its standings`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			seasons, err := getSeasonList(cmdBuilder)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			season := getCurrentSeason(seasons)
+			if season == nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "No season is running now")
+				return nil
+			}
+			count, _ := cmd.Flags().GetInt("limit")
+			standings, err := getSeasonStandings(cmdBuilder, season, count)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			codec, err := getScoreCodec(cmdBuilder, season.LeaderboardID)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintSeason(season, nil)+PrintSeasonStandings(season, standings, codec))
+			return nil
+		},
+	}
+	cmd.Flags().IntP("limit", "l", DEFAULT_SEASON_STANDINGS_COUNT, "Number of the standings to show")
+	return cmd
+}
+
+func getCmdSeasonStandings(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "standings [season]",
+		Short: "Get the **standings** of the season",
+		Long:  `Get the **standings** of the season, the final standings for the finished seasons`,
+		Args:  matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			season, err := getSeason(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			count, _ := cmd.Flags().GetInt("limit")
+			standings, err := getSeasonStandings(cmdBuilder, season, count)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(standings) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("No standings found for the season **%v**", season.ID))
+				return nil
+			}
+			codec, err := getScoreCodec(cmdBuilder, season.LeaderboardID)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintSeasonStandings(season, standings, codec))
+			return nil
+		},
+	}
+	cmd.Flags().IntP("limit", "l", DEFAULT_SEASON_STANDINGS_COUNT, "Number of the standings to show")
+	return cmd
+}
+
+func getCmdSeasonCreate(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create [season]",
+		Short: "Create a **season** backed by a new leaderboard",
+		Long: `Create a **season** backed by a new leaderboard
+The leaderboard is reset by the reset schedule at the season rollover, the first reset must come after the season ends
+The seasons can not overlap`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			seasonID := args[0]
+			if !seasonIDRegexp.MatchString(seasonID) {
+				return fmt.Errorf("Season name %v is invalid, use lower case letters, digits and dashes", seasonID)
+			}
+			season := &Season{
+				ID:            seasonID,
+				LeaderboardID: SEASON_LEADERBOARD_PREFIX + seasonID,
+			}
+			var err error
+			if season.DateTimeStart, err = parseDateFlag(cmd, "start"); err != nil {
+				return err
+			}
+			if season.DateTimeEnd, err = parseDateFlag(cmd, "end"); err != nil {
+				return err
+			}
+			if season.DateTimeStart.IsZero() || season.DateTimeEnd.IsZero() {
+				return fmt.Errorf("Please specify the season **--start** and **--end** dates")
+			}
+			if !season.DateTimeEnd.After(season.DateTimeStart) {
+				return fmt.Errorf("The season must end after it starts")
+			}
+			resetSchedule, _ := cmd.Flags().GetString("reset")
+			if resetSchedule != "" {
+				season.ResetSchedule = NormalizeCronExpression(resetSchedule)
+				if err := validateSeasonResetSchedule(season, time.Now().UTC()); err != nil {
+					return err
+				}
+			}
+			seasons, err := getSeasonList(cmdBuilder)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			for _, otherSeason := range seasons {
+				if otherSeason.ID == season.ID {
+					return fmt.Errorf("Season **%v** already exists", season.ID)
+				}
+				if season.Overlaps(otherSeason) {
+					return fmt.Errorf("The season overlaps the season **%v** %v - %v", otherSeason.ID,
+						formatTimeAsDate(otherSeason.DateTimeStart), formatTimeAsDate(otherSeason.DateTimeEnd))
+				}
+			}
+
+			payload, _ := json.Marshal(&LeaderboardCreateRequest{
+				ID:            season.LeaderboardID,
+				Authoritative: true,
+				SortOrder:     "desc",
+				Operator:      "incr",
+				ResetSchedule: season.ResetSchedule,
+				Metadata:      DEFAULT_SCORE_CODEC.Metadata(),
+			})
+			log.Infof("%+v\n", string(payload))
+			if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "LeaderboardCreate", Payload: string(payload)}); err != nil {
+				log.Error(err)
+				return err
+			}
+
+			payload, _ = json.Marshal(&SeasonCreateRequest{Season: season})
+			log.Infof("%+v\n", string(payload))
+			if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "SeasonCreate", Payload: string(payload)}); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), "> Season created:\n"+PrintSeason(season, nil))
+			return nil
+		},
+	}
+	cmd.Flags().StringP("start", "", "", "Season start date: "+DATE_LAYOUT)
+	cmd.Flags().StringP("end", "", "", "Season end date: "+DATE_LAYOUT)
+	cmd.Flags().StringP("reset", "r", "", "Leaderboard reset schedule in the cron format, e.g. 0 0 1 */3 *")
+	return cmd
+}
+
+func getCmdSeasonArchive(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archive [season]",
+		Short: "Snapshot the **final standings** of the season",
+		Long: `Snapshot the **final standings** of the season into the season archive
+The seasons are archived by the server at the end, use it only if that has failed and the leaderboard is not reset yet`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			season, err := getSeason(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			seasonArchive, err := archiveSeason(cmdBuilder, season)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), "> Season archived:\n"+PrintSeason(season, seasonArchive))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func getCmdSeason(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "season",
+		Short: "League **seasons** and their standings",
+		Long:  `League **seasons** and their standings`,
+	}
+	cmd.AddCommand(getCmdSeasonList(cmdBuilder))
+	cmd.AddCommand(getCmdSeasonCurrent(cmdBuilder))
+	cmd.AddCommand(getCmdSeasonStandings(cmdBuilder))
+	return cmd
+}
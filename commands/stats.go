@@ -0,0 +1,281 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	USER_STATS_COLLECTION = "user_stats_data"
+	USER_STATS_KEY        = "stats"
+
+	USER_STATS_TOP_COUNT          = 3
+	USER_STATS_COIN_HISTORY_COUNT = 5
+)
+
+type ModeStats struct {
+	Matches int
+	Wins    int
+	Losses  int
+	Draws   int
+}
+
+type OpponentStats struct {
+	Matches int
+	Wins    int
+	Losses  int
+}
+
+type CoinBalance struct {
+	MatchID  string
+	DateTime time.Time
+	Reward   float64
+	Coins    float64
+}
+
+// UserStats is the per-user aggregate of the archived matches and submits.
+// The server keeps it in USER_STATS_COLLECTION, it is updated when a match is
+// finalised and recomputed when an archived match is edited, the client only
+// reads it. Teammates and Opponents are keyed by the discord ID.
+type UserStats struct {
+	UserID            string
+	Modes             map[string]*ModeStats
+	Streak            int
+	SubmitCount       int
+	SubmitScoreSum    float64
+	Teammates         map[string]int
+	Opponents         map[string]*OpponentStats
+	CoinHistory       []*CoinBalance
+	LastMatchDateTime time.Time
+	Version           string
+}
+
+type UserStatsCount struct {
+	DiscordID string
+	Count     int
+}
+
+func NewUserStats(userID string) *UserStats {
+	return &UserStats{
+		UserID:    userID,
+		Modes:     make(map[string]*ModeStats),
+		Teammates: make(map[string]int),
+		Opponents: make(map[string]*OpponentStats),
+	}
+}
+
+func (s *UserStats) Total() *ModeStats {
+	total := &ModeStats{}
+	for _, modeStats := range s.Modes {
+		total.Matches += modeStats.Matches
+		total.Wins += modeStats.Wins
+		total.Losses += modeStats.Losses
+		total.Draws += modeStats.Draws
+	}
+	return total
+}
+
+func (m *ModeStats) WinRate() float64 {
+	if m.Matches == 0 {
+		return 0
+	}
+	return float64(m.Wins) / float64(m.Matches) * 100
+}
+
+func (s *UserStats) AverageScore() float64 {
+	if s.SubmitCount == 0 {
+		return 0
+	}
+	return s.SubmitScoreSum / float64(s.SubmitCount)
+}
+
+func (s *UserStats) FavouriteTeammates() []*UserStatsCount {
+	var counts []*UserStatsCount
+	for discordID, count := range s.Teammates {
+		counts = append(counts, &UserStatsCount{DiscordID: discordID, Count: count})
+	}
+	return topUserStatsCounts(counts)
+}
+
+func (s *UserStats) Nemeses() []*UserStatsCount {
+	var counts []*UserStatsCount
+	for discordID, opponentStats := range s.Opponents {
+		if opponentStats.Losses > 0 {
+			counts = append(counts, &UserStatsCount{DiscordID: discordID, Count: opponentStats.Losses})
+		}
+	}
+	return topUserStatsCounts(counts)
+}
+
+func topUserStatsCounts(counts []*UserStatsCount) []*UserStatsCount {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count == counts[j].Count {
+			return counts[i].DiscordID < counts[j].DiscordID
+		}
+		return counts[i].Count > counts[j].Count
+	})
+	if len(counts) > USER_STATS_TOP_COUNT {
+		return counts[:USER_STATS_TOP_COUNT]
+	}
+	return counts
+}
+
+// GetCoinHistory returns the last balances restored backwards from the
+// current wallet balance
+func (s *UserStats) GetCoinHistory(coins float64) []*CoinBalance {
+	var history []*CoinBalance
+	for i := len(s.CoinHistory) - 1; i >= 0 && len(history) < USER_STATS_COIN_HISTORY_COUNT; i-- {
+		history = append(history, &CoinBalance{
+			MatchID:  s.CoinHistory[i].MatchID,
+			DateTime: s.CoinHistory[i].DateTime,
+			Reward:   s.CoinHistory[i].Reward,
+			Coins:    coins,
+		})
+		coins -= s.CoinHistory[i].Reward
+	}
+	return history
+}
+
+func PrintUserStats(account *api.Account, userStats *UserStats) string {
+	total := userStats.Total()
+	msg := fmt.Sprintf("> Profile: <@%v>\n", account.CustomId) +
+		ExecuteTemplate(
+			"```"+DISCORD_BLOCK_CODE_TYPE+"\n"+
+				`Matches: {{.Total.Matches}}
+Wins: {{.Total.Wins}}
+Losses: {{.Total.Losses}}
+Draws: {{.Total.Draws}}
+WinRate: {{printf "%.1f" .Total.WinRate}}%
+Streak: {{.Streak}}
+AverageScore: {{printf "%.4f" .AverageScore}}
+Submits: {{.SubmitCount}}`+"```\n",
+			map[string]interface{}{
+				"Total":        total,
+				"Streak":       userStats.Streak,
+				"AverageScore": userStats.AverageScore(),
+				"SubmitCount":  userStats.SubmitCount,
+			})
+
+	var modes []string
+	for mode := range userStats.Modes {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	for _, mode := range modes {
+		modeStats := userStats.Modes[mode]
+		msg += fmt.Sprintf("> **%v**: %v matches, %v W / %v L / %v D, win rate %.1f%%\n",
+			mode, modeStats.Matches, modeStats.Wins, modeStats.Losses, modeStats.Draws, modeStats.WinRate())
+	}
+
+	msg += ExecuteTemplate(
+		`{{if .Teammates}}> Favourite teammates:{{range .Teammates}} <@{{.DiscordID}}> ({{.Count}}){{end}}
+{{end}}{{if .Nemeses}}> Nemeses:{{range .Nemeses}} <@{{.DiscordID}}> ({{.Count}}){{end}}
+{{end}}{{if .Coins}}> Coins:
+{{range .Coins}}>   {{.DateTime | formatTimeAsDate}} **{{.Coins}}**{{ if .Reward | isFloatPositive }} (+{{.Reward}}){{end}}{{ if .Reward | isFloatNegative }} ({{.Reward}}){{end}}
+{{end}}{{end}}`,
+		map[string]interface{}{
+			"Teammates": userStats.FavouriteTeammates(),
+			"Nemeses":   userStats.Nemeses(),
+			"Coins":     userStats.GetCoinHistory(getCoinsFromWallet(account.Wallet)),
+		})
+	return msg
+}
+
+func getUserStats(cmdBuilder *commandsBuilder, account *api.Account) (*UserStats, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, USER_STATS_COLLECTION, USER_STATS_KEY, account.User.Id)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if len(storageObjects) == 0 {
+		return NewUserStats(account.User.Id), nil
+	}
+
+	userStats := NewUserStats(account.User.Id)
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), userStats); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	userStats.Version = storageObjects[0].Version
+	return userStats, nil
+}
+
+func getUserSubmits(cmdBuilder *commandsBuilder, matchID string, userID string) (*Submits, error) {
+	storageObjects, err := readUserStorageObjects(cmdBuilder, SUBMIT_COLLECTION, matchID, userID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if len(storageObjects) == 0 {
+		return nil, nil
+	}
+
+	var submits *Submits
+	if err := json.Unmarshal([]byte(storageObjects[0].Value), &submits); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	submits.Version = storageObjects[0].Version
+	return submits, nil
+}
+
+func getCmdUserProfile(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "profile [user]",
+		Aliases: []string{"stats"},
+		Short:   "Get the user **profile** and statistics",
+		Long:    `Get the user **profile** and statistics`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(args) > 0 {
+				if account, err = getAccount(cmdBuilder, args[0]); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			userStats, err := getUserStats(cmdBuilder, account)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			userPenalties, err := getUserPenaltyList(cmdBuilder, account)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), PrintAccount(account)+PrintUserStats(account, userStats)+PrintUserPenalties(userPenalties))
+			return nil
+		},
+	}
+	return cmd
+}
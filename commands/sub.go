@@ -0,0 +1,156 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// MatchSubstituteRequest replaces OutUserID in the captain's team by
+// InTeamUser, the server moves the outgoing TeamUser to Team.SubstitutedUsers.
+// The swap, the InTicketState of the incoming user and closing the ticket of
+// the outgoing user are written in one server call, so a failed write leaves
+// the match untouched. When the match is finalised the server pays every user
+// of the match, substituted ones included, the reward scaled by
+// GetProRatedReward and stores the paid amount in TeamUser.Reward
+type MatchSubstituteRequest struct {
+	MatchID       string
+	CaptainUserID string
+	OutUserID     string
+	InTeamUser    *TeamUser
+	InTicketState *TicketState
+	DateTime      time.Time
+}
+
+func validateSubstitute(cmdBuilder *commandsBuilder, account *api.Account, matchState *MatchState, outAccount *api.Account, inAccount *api.Account) error {
+	if !matchState.Started || matchState.Status != MATCH_STATUS_IN_PROGRESS {
+		return fmt.Errorf("The match **%v** is not in progress", matchState.MatchID)
+	}
+	captain, captainTeamNumber := GetUserAndTeamNumberByUserID(account.User.Id, matchState)
+	if captain == nil || !captain.Captain {
+		return fmt.Errorf("Only captains can substitute players")
+	}
+	outTeamUser, outTeamNumber := GetUserAndTeamNumberByUserID(outAccount.User.Id, matchState)
+	if outTeamUser == nil || outTeamNumber != captainTeamNumber {
+		return fmt.Errorf("<@%v> is not in your team", outAccount.CustomId)
+	}
+	if outTeamUser.Captain {
+		return fmt.Errorf("Captain <@%v> can not be substituted", outAccount.CustomId)
+	}
+	if err := checkUserCooldown(cmdBuilder, inAccount); err != nil {
+		return err
+	}
+	if IsUserIDInMatch(inAccount.User.Id, matchState) {
+		return fmt.Errorf("<@%v> is already in the match **%v**", inAccount.CustomId, matchState.MatchID)
+	}
+	ticketState, err := getLastUserTicketState(cmdBuilder, inAccount)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if ticketState != nil {
+		return fmt.Errorf("<@%v> already has the ticket **%v**, please cancel it first", inAccount.CustomId, ticketState.Ticket.Id)
+	}
+	return nil
+}
+
+func getCmdSubstitute(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "sub [out_user] [in_user]",
+		Aliases: []string{"substitute"},
+		Short:   "**Substitute** a player of your team during the match",
+		Long: `**Substitute** a player of your team during the match
+Only the captain can substitute players, rewards are pro-rated by the time played`,
+		Args: matchAll(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			matchState, err := getLastUserMatchState(cmdBuilder, account, MATCH_COLLECTION)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchState == nil {
+				return fmt.Errorf("No match found for <@%v>", account.CustomId)
+			}
+
+			outAccount, err := getAccount(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			inAccount, err := getAccount(cmdBuilder, args[1])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if err := validateSubstitute(cmdBuilder, account, matchState, outAccount, inAccount); err != nil {
+				log.Error(err)
+				return err
+			}
+
+			ticketState, err := buildCaptainsDraftTicketState(cmdBuilder, inAccount, matchState.MatchID, true,
+				int(matchState.Duration.Hours()), []string{matchState.MatchProfile})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			now := time.Now().UTC()
+			var inTeamUser *TeamUser
+			if err := json.Unmarshal(ticketState.Ticket.Extensions[TICKET_EXTENSION_USER].Value, &inTeamUser); err != nil {
+				log.Error(err)
+				return err
+			}
+			inTeamUser.TicketID = ticketState.Ticket.Id
+			inTeamUser.DateTimeJoin = now
+
+			payload, _ := json.Marshal(&MatchSubstituteRequest{
+				MatchID:       matchState.MatchID,
+				CaptainUserID: account.User.Id,
+				OutUserID:     outAccount.User.Id,
+				InTeamUser:    inTeamUser,
+				InTicketState: ticketState,
+				DateTime:      now,
+			})
+			log.Infof("%+v\n", string(payload))
+
+			result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchSubstitute", Payload: string(payload)})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			if result.Payload != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), result.Payload)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
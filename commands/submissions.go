@@ -0,0 +1,146 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func GetBestSubmit(submits *Submits, codec *ScoreCodec) *Submit {
+	var best *Submit
+	for _, submit := range submits.Submits {
+		if best == nil || codec.Compare(submit.Score, submit.Subscore, best.Score, best.Subscore) > 0 {
+			best = submit
+		}
+	}
+	return best
+}
+
+// GetSubmitAttemptsLeft returns -1 if the number of submits is not limited
+func GetSubmitAttemptsLeft(matchState *MatchState, submits *Submits) int {
+	if matchState.MaxNumScore <= 0 {
+		return -1
+	}
+	attemptsLeft := matchState.MaxNumScore
+	if submits != nil {
+		attemptsLeft -= len(submits.Submits)
+	}
+	if attemptsLeft < 0 {
+		return 0
+	}
+	return attemptsLeft
+}
+
+func PrintSubmits(account *api.Account, matchState *MatchState, submits *Submits, codec *ScoreCodec) string {
+	msg := fmt.Sprintf("> Submissions of <@%v> in the match `%v`:\n", account.CustomId, matchState.MatchID)
+	if submits == nil || len(submits.Submits) == 0 {
+		msg += "> No submissions yet\n"
+	} else {
+		best := GetBestSubmit(submits, codec)
+		for i, submit := range submits.Submits {
+			if submit == best {
+				msg += fmt.Sprintf("> **#%v best**\n", i+1)
+			} else {
+				msg += fmt.Sprintf("> #%v\n", i+1)
+			}
+			msg += PrintSubmit(submit, codec)
+		}
+	}
+	if attemptsLeft := GetSubmitAttemptsLeft(matchState, submits); attemptsLeft >= 0 {
+		msg += fmt.Sprintf("> Attempts left: **%v** of %v\n", attemptsLeft, matchState.MaxNumScore)
+	}
+	return msg
+}
+
+func getMatchStateFromAnyCollection(cmdBuilder *commandsBuilder, matchID string) (*MatchState, error) {
+	matchState, err := getMatchState(cmdBuilder, matchID, MATCH_COLLECTION)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if matchState != nil {
+		return matchState, nil
+	}
+	return getMatchState(cmdBuilder, matchID, MATCH_ARCHIVE_COLLECTION)
+}
+
+func getCmdSubmissions(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "submissions [matchID] [user]",
+		Aliases: []string{"subs"},
+		Short:   "List the **submissions** of a user in the match with the best one marked",
+		Long: `List the **submissions** of a user in the match with the best one marked
+The last match of the user is used if the match ID is not specified`,
+		Args: matchAll(cobra.MaximumNArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			matchID, user := "", ""
+			switch {
+			case len(args) == 2:
+				matchID, user = args[0], args[1]
+			case len(args) == 1 && detectUserIdentity(args[0]) != DiscordUndefined:
+				user = args[0]
+			case len(args) == 1:
+				matchID = args[0]
+			}
+			if user != "" {
+				if account, err = getAccount(cmdBuilder, user); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			var matchState *MatchState
+			if matchID != "" {
+				matchState, err = getMatchStateFromAnyCollection(cmdBuilder, matchID)
+			} else {
+				matchState, err = getLastUserMatchState(cmdBuilder, account, MATCH_COLLECTION)
+			}
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if matchState == nil {
+				return fmt.Errorf("No match found for <@%v>", account.CustomId)
+			}
+
+			submits, err := getUserSubmits(cmdBuilder, matchState.MatchID, account.User.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			codec, err := getMatchScoreCodec(cmdBuilder, matchState)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintSubmits(account, matchState, submits, codec))
+			return nil
+		},
+	}
+	return cmd
+}
@@ -18,8 +18,6 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
-	"math"
-	"strconv"
 	"strings"
 	"time"
 
@@ -34,10 +32,13 @@ const (
 )
 
 type Submit struct {
-	Datetime  time.Time
-	Score     int64
-	Subscore  int64
-	ProofLink string
+	Datetime    time.Time
+	Score       int64
+	Subscore    int64
+	ProofLink   string
+	ProofStatus string
+	ProofScore  string
+	ProofReused bool
 }
 
 type Submits struct {
@@ -53,10 +54,10 @@ type SubmitCreateRequest struct {
 	UserID  string
 }
 
-func PrintSubmit(submit *Submit) string {
+func PrintSubmit(submit *Submit, codec *ScoreCodec) string {
 	return ExecuteTemplate(
 		"```"+DISCORD_BLOCK_CODE_TYPE+"\n"+
-			`Score: {{.Score}}.{{.Subscore}} | Date: {{.Datetime | formatTimeAsDate}} | Proof: {{.ProofLink}}`+"```\n",
+			`Score: `+codec.Decode(submit.Score, submit.Subscore)+` | Date: {{.Datetime | formatTimeAsDate}} | Proof: {{.ProofLink}}`+PrintProofStatus(submit)+"```\n",
 		submit)
 }
 
@@ -70,31 +71,16 @@ func getCmdSubmit(cmdBuilder *commandsBuilder) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
 			matchID, _ := cmd.Flags().GetString("matchID")
-			scoreF, _ := cmd.Flags().GetFloat64("score")
+			scoreValue, _ := cmd.Flags().GetString("score")
 			proof, _ := cmd.Flags().GetString("proof")
-			var err error
-			if scoreF == 0 && len(args) >= 2 {
-				if scoreF, err = strconv.ParseFloat(args[0], 64); err != nil {
-					log.Error(err)
-					return err
-				}
+			if scoreValue == "" && len(args) >= 2 {
+				scoreValue = args[0]
 			}
 
-			if scoreF == 0 {
+			if scoreValue == "" {
 				return fmt.Errorf("score is **required** and must not equal 0")
 			}
 
-			score := int64(scoreF)
-			_, subscoreF := math.Modf(scoreF)
-			subscore := int64(0)
-			if subscoreF != 0 {
-				subscore, err = strconv.ParseInt(fmt.Sprintf("%.10f", subscoreF)[2:], 10, 64)
-				if err != nil {
-					log.Error(err)
-					return err
-				}
-			}
-
 			if proof == "" && len(args) >= 2 {
 				proof = args[1]
 			}
@@ -122,9 +108,9 @@ func getCmdSubmit(cmdBuilder *commandsBuilder) *cobra.Command {
 
 			var matchState *MatchState
 			if matchID != "" {
-				matchState, err = getMatchState(cmdBuilder, matchID, MATCH_COLLECTION)
+				matchState, err = getMatchStateFromAnyCollection(cmdBuilder, matchID)
 			} else {
-				matchState, err = getLastUserMatchState(cmdBuilder, account, MATCH_COLLECTION)
+				matchState, err = getLastUserSubmissionMatchState(cmdBuilder, account)
 			}
 
 			if err != nil {
@@ -137,17 +123,58 @@ func getCmdSubmit(cmdBuilder *commandsBuilder) *cobra.Command {
 				return nil
 			}
 
-			if !(matchState.Active && matchState.Started) {
-				fmt.Fprintf(cmd.OutOrStdout(), "Match is not active or not started yet")
-				return nil
+			if !IsUserIDInMatch(account.User.Id, matchState) {
+				return fmt.Errorf("<@%v> is not a player of the match **%v**", account.CustomId, matchState.MatchID)
+			}
+
+			serverTime, err := getServerTime(cmdBuilder)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if err := ValidateSubmissionWindow(matchState, GetSubmitWindow(matchState), serverTime); err != nil {
+				return err
+			}
+
+			submits, err := getUserSubmits(cmdBuilder, matchState.MatchID, account.User.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if err := ValidateSubmitAttempts(matchState, submits); err != nil {
+				return err
+			}
+
+			codec, err := getMatchScoreCodec(cmdBuilder, matchState)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			score, subscore, err := codec.Encode(scoreValue)
+			if err != nil {
+				return err
+			}
+			if score == 0 && subscore == 0 {
+				return fmt.Errorf("score is **required** and must not equal 0")
+			}
+
+			submit := &Submit{
+				Datetime:  serverTime,
+				ProofLink: proof,
+				Score:     score,
+				Subscore:  subscore,
+			}
+			proofContent := fetchProofLink(cmdBuilder, proof)
+			submit.ProofStatus, submit.ProofScore = cmdBuilder.GetProofVerifiers().Verify(proofContent, submit, codec)
+			proofRegisterRequest, proofReused, err := checkProofReuse(cmdBuilder, cmd, account, matchState.MatchID, PROOF_USE_SUBMIT, proof, proofContent)
+			if err != nil {
+				log.Error(err)
+				return err
 			}
+			submit.ProofReused = proofReused
 
 			payload, _ := json.Marshal(&SubmitCreateRequest{
-				Submit: &Submit{
-					ProofLink: proof,
-					Score:     score,
-					Subscore:  subscore,
-				},
+				Submit:  submit,
 				MatchID: matchState.MatchID,
 				UserID:  account.User.Id,
 			})
@@ -158,11 +185,11 @@ func getCmdSubmit(cmdBuilder *commandsBuilder) *cobra.Command {
 				log.Error(err)
 				return err
 			}
-			return nil
+			return registerProof(cmdBuilder, proofRegisterRequest)
 		},
 	}
 	cmdSubmit.Flags().StringP("matchID", "m", "", "Match ID")
-	cmdSubmit.Flags().Float64P("score", "s", 0, "Score value **must** be positive float number. ")
+	cmdSubmit.Flags().StringP("score", "s", "", "Score value **must** be a decimal number, e.g. 12.34 or -0.05")
 	cmdSubmit.Flags().StringP("proof", "p", "", "Proof link **must** be a valid URL starting with **http** or **https**")
 	return cmdSubmit
 }
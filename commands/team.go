@@ -15,18 +15,26 @@ limitations under the License.
 */
 package commands
 
+import (
+	"fmt"
+	"time"
+)
+
 type Team struct {
-	ID              int
-	Name            string
-	TeamUsers       []*TeamUser
-	DiscordChannels []*DiscordChannel
+	ID               int
+	Name             string
+	TeamUsers        []*TeamUser
+	DiscordChannels  []*DiscordChannel
+	SubstitutedUsers []*TeamUser
 }
 
 type TeamUser struct {
-	User     *User
-	TicketID string
-	Reward   float64
-	Captain  bool
+	User          *User
+	TicketID      string
+	Reward        float64
+	Captain       bool
+	DateTimeJoin  time.Time
+	DateTimeLeave time.Time
 }
 
 func PrintTeams(teams []*Team) string {
@@ -45,6 +53,48 @@ func PrintTeam(team *Team) string {
 	return msg
 }
 
+// GetTeamUserPlayedDuration returns the time the user actually played,
+// substitutes join and leave in the middle of the match
+func GetTeamUserPlayedDuration(teamUser *TeamUser, matchState *MatchState) time.Duration {
+	start := matchState.DateTimeStart
+	if teamUser.DateTimeJoin.After(start) {
+		start = teamUser.DateTimeJoin
+	}
+	end := GetMatchDateTimeEnd(matchState)
+	if !teamUser.DateTimeLeave.IsZero() && teamUser.DateTimeLeave.Before(end) {
+		end = teamUser.DateTimeLeave
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// GetProRatedReward scales the team reward by the share of the match the
+// user played
+func GetProRatedReward(reward float64, teamUser *TeamUser, matchState *MatchState) float64 {
+	duration := GetMatchDateTimeEnd(matchState).Sub(matchState.DateTimeStart)
+	if duration <= 0 {
+		return reward
+	}
+	share := float64(GetTeamUserPlayedDuration(teamUser, matchState)) / float64(duration)
+	if share > 1 {
+		share = 1
+	}
+	return reward * share
+}
+
+func PrintSubstitutedUsers(teams []*Team) string {
+	msg := ""
+	for _, team := range teams {
+		for _, teamUser := range team.SubstitutedUsers {
+			msg += fmt.Sprintf("> Substituted in team **%v**: <@%v> left at %v\n",
+				team.ID, teamUser.User.Nakama.CustomID, formatTimeAsDate(teamUser.DateTimeLeave))
+		}
+	}
+	return msg
+}
+
 func GetUsersFromTeam(team *Team) []*User {
 	var users []*User
 	for _, v := range team.TeamUsers {
@@ -131,6 +181,17 @@ func GetUserAndTeamNumberByUserID(userID string, matchState *MatchState) (*TeamU
 	return nil, -1
 }
 
+func GetSubstitutedUserByUserID(userID string, matchState *MatchState) *TeamUser {
+	for _, team := range matchState.Teams {
+		for _, v := range team.SubstitutedUsers {
+			if v.User.Nakama.ID == userID {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
 func GetUsersReady(matchState *MatchState) map[int][]*UserReady {
 	teamUsersReadyMap := make(map[int][]*UserReady)
 	for k, team := range matchState.Teams {
@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetProRatedReward(t *testing.T) {
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	matchState := &MatchState{
+		DateTimeStart: start,
+		DateTimeEnd:   start.Add(time.Hour),
+	}
+	for _, test := range []struct {
+		name     string
+		teamUser *TeamUser
+		played   time.Duration
+		reward   float64
+	}{
+		{"whole match", &TeamUser{}, time.Hour, 10},
+		{"subbed in", &TeamUser{DateTimeJoin: start.Add(20 * time.Minute)}, 40 * time.Minute, 10 * 40 / 60.},
+		{"subbed out", &TeamUser{DateTimeLeave: start.Add(15 * time.Minute)}, 15 * time.Minute, 2.5},
+		{"subbed in and out", &TeamUser{DateTimeJoin: start.Add(10 * time.Minute), DateTimeLeave: start.Add(40 * time.Minute)}, 30 * time.Minute, 5},
+		{"joined before the start", &TeamUser{DateTimeJoin: start.Add(-time.Hour)}, time.Hour, 10},
+		{"left after the end", &TeamUser{DateTimeLeave: start.Add(2 * time.Hour)}, time.Hour, 10},
+		{"joined after the end", &TeamUser{DateTimeJoin: start.Add(2 * time.Hour)}, 0, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if played := GetTeamUserPlayedDuration(test.teamUser, matchState); played != test.played {
+				t.Errorf("played %v, want %v", played, test.played)
+			}
+			if reward := GetProRatedReward(10, test.teamUser, matchState); math.Abs(reward-test.reward) > 1e-9 {
+				t.Errorf("reward %v, want %v", reward, test.reward)
+			}
+		})
+	}
+}
+
+func TestGetProRatedRewardActualEnd(t *testing.T) {
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	matchState := &MatchState{
+		DateTimeStart:     start,
+		DateTimeEnd:       start.Add(time.Hour),
+		ActualDateTimeEnd: start.Add(30 * time.Minute),
+	}
+	teamUser := &TeamUser{DateTimeLeave: start.Add(15 * time.Minute)}
+	if reward := GetProRatedReward(10, teamUser, matchState); reward != 5 {
+		t.Errorf("reward %v, want 5", reward)
+	}
+
+	matchState.ActualDateTimeEnd = start
+	if reward := GetProRatedReward(10, teamUser, matchState); reward != 10 {
+		t.Errorf("reward %v of a match with no duration, want the whole reward 10", reward)
+	}
+}
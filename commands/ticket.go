@@ -37,6 +37,8 @@ const (
 	TICKET_COLLECTION          = "ticket_data"
 	TICKET_EXTENSION_USER      = "user"
 	MATCH_EXTENSION_MATCH_TYPE = "match_type"
+	MATCH_EXTENSION_TEAMS      = "teams"
+	MATCH_EXTENSION_REMATCH_OF = "rematch_of"
 )
 
 var cmdTicketAliases = []string{"go", "new", "search", "ticket", "t"}
@@ -49,6 +51,7 @@ type TicketState struct {
 	DiscordID     string
 	Version       string
 	UserReady     bool
+	Closed        bool
 }
 
 type TicketStateCreateRequest struct {
@@ -130,9 +133,27 @@ func PrintTicketSearchFields(TicketState *TicketState) string {
 }
 
 func createCaptainsDraftTicketState(cmdBuilder *commandsBuilder, cmd *cobra.Command, account *api.Account, matchID string, ready bool) (*TicketState, error) {
-	log.Infof("%+v", account)
 	duration, _ := cmd.Flags().GetInt("duration")
 	tags, _ := cmd.Flags().GetStringSlice("mode")
+	return newCaptainsDraftTicketState(cmdBuilder, account, matchID, ready, duration, tags)
+}
+
+func newCaptainsDraftTicketState(cmdBuilder *commandsBuilder, account *api.Account, matchID string, ready bool, duration int, tags []string) (*TicketState, error) {
+	ticketState, err := buildCaptainsDraftTicketState(cmdBuilder, account, matchID, ready, duration, tags)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if err := writeTicketState(cmdBuilder, account, ticketState); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return ticketState, nil
+}
+
+// buildCaptainsDraftTicketState prepares the ticket state without writing it
+func buildCaptainsDraftTicketState(cmdBuilder *commandsBuilder, account *api.Account, matchID string, ready bool, duration int, tags []string) (*TicketState, error) {
+	log.Infof("%+v", account)
 	userData, err := getLastUserData(cmdBuilder, account)
 	if err != nil {
 		log.Error(err)
@@ -180,13 +201,17 @@ func createCaptainsDraftTicketState(cmdBuilder *commandsBuilder, cmd *cobra.Comm
 		UserReady:     ready,
 		CaptainsDraft: true,
 	}
+	return ticketState, nil
+}
 
+// writeTicketState stores the ticket state and points the user data to it
+func writeTicketState(cmdBuilder *commandsBuilder, account *api.Account, ticketState *TicketState) error {
 	if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TicketStateCreate", Payload: string(Marshal(&TicketStateCreateRequest{
 		UserID:      account.User.Id,
 		TicketState: ticketState,
 	}))}); err != nil {
 		log.Error(err)
-		return nil, err
+		return err
 	}
 
 	if err := createOrUpdateLastUserData(cmdBuilder, account, &UserData{
@@ -195,10 +220,10 @@ func createCaptainsDraftTicketState(cmdBuilder *commandsBuilder, cmd *cobra.Comm
 		TicketID: ticketState.Ticket.Id,
 	}); err != nil {
 		log.Error(err)
-		return nil, err
+		return err
 	}
 
-	return ticketState, nil
+	return nil
 }
 
 func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string, isCaptainsDraftMode bool) error {
@@ -209,8 +234,10 @@ func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 			return fmt.Errorf("Match mode %v is invalid. Available match modes: %+v", matchMode[0], CAPTAIN_DRAFT_MODES)
 		}
 	} else {
-		if !IsStringInSlice(matchMode[0], CAPTAIN_DRAFT_MODES) {
-			return fmt.Errorf("Match mode %v is invalid. Available match modes: %+v", matchMode[0], CAPTAIN_DRAFT_MODES)
+		for _, mode := range matchMode {
+			if !IsStringInSlice(mode, MATCH_MAKER_MODES) {
+				return fmt.Errorf("Match mode %v is invalid. Available match modes: %+v", mode, MATCH_MAKER_MODES)
+			}
 		}
 	}
 	ready, _ := cmd.Flags().GetBool("ready")
@@ -229,6 +256,11 @@ func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 		return fmt.Errorf(fmt.Sprint("duration can not be more than %v hours - this is not a Kaggle", MAX_MATCH_DURATION_HOURS))
 	}
 
+	readyDeadline, _ := cmd.Flags().GetInt("ready-deadline")
+	if readyDeadline < MIN_READY_DEADLINE_MINUTES || readyDeadline > MAX_READY_DEADLINE_MINUTES {
+		return fmt.Errorf("ready deadline must be between %v and %v minutes", MIN_READY_DEADLINE_MINUTES, MAX_READY_DEADLINE_MINUTES)
+	}
+
 	account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
 	if err != nil {
 		log.Error(err)
@@ -241,7 +273,17 @@ func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 		return err
 	}
 
-	if lastTicketState != nil {
+	if err := checkUserCooldown(cmdBuilder, account); err != nil {
+		return err
+	}
+
+	// the tickets of a scheduled match are created when it opens
+	dateTimeScheduled, err := parseScheduleFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	if lastTicketState != nil && dateTimeScheduled.IsZero() {
 		fmt.Fprintf(cmd.OutOrStdout(),
 			fmt.Sprintf("<@%v> already has a ticket. Please cancel the following ticket or finish the following match:\n"+
 				PrintTicketState(lastTicketState), account.CustomId))
@@ -278,38 +320,76 @@ func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 			return err
 		}
 
-		if lastOpponentTicketState != nil {
+		if lastOpponentTicketState != nil && dateTimeScheduled.IsZero() {
 			fmt.Fprintf(cmd.OutOrStdout(),
 				fmt.Sprintf("<@%v> already has a ticket. Please cancel the following ticket or finish the following match:\n"+
 					PrintTicketState(lastOpponentTicketState), opponentAccount.CustomId))
 			return nil
 		}
 
-		matchID := uuid.Must(uuid.NewV4()).String()
+		if err := checkUserCooldown(cmdBuilder, opponentAccount); err != nil {
+			return err
+		}
 
-		ticketState, err := createCaptainsDraftTicketState(cmdBuilder, cmd, account, matchID, ready)
-		if err != nil {
+		if !dateTimeScheduled.IsZero() {
+			// readiness is collected once the scheduled match moves to awaiting users ready
+			ready = false
+		}
+
+		leaderboardID := GetMatchLeaderboardID(matchMode[0], MATCH_TYPE_CAPTAINS_DRAFT, time.Duration(duration)*time.Hour)
+		if err := ensureLeaderboard(cmdBuilder, leaderboardID); err != nil {
 			log.Error(err)
 			return err
 		}
-		opponentTicketState, err := createCaptainsDraftTicketState(cmdBuilder, cmd, opponentAccount, matchID, false)
+		seasonLeaderboardID, err := getCurrentSeasonLeaderboardID(cmdBuilder)
 		if err != nil {
 			log.Error(err)
 			return err
 		}
 
+		matchID := uuid.Must(uuid.NewV4()).String()
+
+		tags, _ := cmd.Flags().GetStringSlice("mode")
+		var ticketStates []*TicketState
 		var tickets []*pb.Ticket
-		tickets = append(tickets, ticketState.Ticket)
-		tickets = append(tickets, opponentTicketState.Ticket)
+		for _, userAccount := range []*api.Account{account, opponentAccount} {
+			var ticketState *TicketState
+			if dateTimeScheduled.IsZero() {
+				ticketState, err = newCaptainsDraftTicketState(cmdBuilder, userAccount, matchID, ready && userAccount == account, duration, tags)
+			} else {
+				ticketState, err = buildCaptainsDraftTicketState(cmdBuilder, userAccount, matchID, false, duration, tags)
+			}
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			ticketStates = append(ticketStates, ticketState)
+			tickets = append(tickets, ticketState.Ticket)
+		}
 
 		match := &pb.Match{
 			MatchId:      matchID,
 			MatchProfile: matchMode[0],
 			Tickets:      tickets,
 			Extensions: map[string]*anypb.Any{
-				MATCH_EXTENSION_MATCH_TYPE: &anypb.Any{Value: Marshal(MATCH_TYPE_CAPTAINS_DRAFT)},
+				MATCH_EXTENSION_MATCH_TYPE:            &anypb.Any{Value: Marshal(MATCH_TYPE_CAPTAINS_DRAFT)},
+				MATCH_EXTENSION_READY_DEADLINE:        &anypb.Any{Value: Marshal(time.Duration(readyDeadline) * time.Minute)},
+				MATCH_EXTENSION_ROUTED_LEADERBOARD_ID: &anypb.Any{Value: Marshal(leaderboardID)},
 			},
 		}
+		if seasonLeaderboardID != "" {
+			match.Extensions[MATCH_EXTENSION_SEASON_LEADERBOARD_ID] = &anypb.Any{Value: Marshal(seasonLeaderboardID)}
+		}
+		if !dateTimeScheduled.IsZero() {
+			scheduledTeamUsers, err := NewScheduledTeamUsers(ticketStates, time.Now().UTC())
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			match.Extensions[MATCH_EXTENSION_DATE_TIME_SCHEDULED] = &anypb.Any{Value: Marshal(dateTimeScheduled)}
+			match.Extensions[MATCH_EXTENSION_SCHEDULE_REMINDERS] = &anypb.Any{Value: Marshal(GetScheduleReminders(dateTimeScheduled))}
+			match.Extensions[MATCH_EXTENSION_SCHEDULED_TEAM_USERS] = &anypb.Any{Value: Marshal(scheduledTeamUsers)}
+		}
 
 		result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchCreate", Payload: string(Marshal(match))})
 		if err != nil {
@@ -318,7 +398,28 @@ func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 		}
 		log.Infof("%+v\n", MarshalIndent(result))
 
-		fmt.Fprintf(cmd.OutOrStdout(), PrintTicketState(ticketState))
+		if dateTimeScheduled.IsZero() {
+			fmt.Fprintf(cmd.OutOrStdout(), PrintTicketState(ticketStates[0]))
+		} else {
+			payload, _ := json.Marshal(&MatchScheduleRequest{
+				MatchID: matchID,
+			})
+			log.Infof("%+v\n", string(payload))
+
+			if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "MatchSchedule", Payload: string(payload)}); err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("> The match **%v** is scheduled for **%v**, the tickets are created then and every player has to type **dl ready**\n",
+				matchID, formatTimeAsDate(dateTimeScheduled)))
+		}
+
+		userStats, err := getUserStats(cmdBuilder, account)
+		if err != nil {
+			log.Error(err)
+		} else {
+			fmt.Fprint(cmd.OutOrStdout(), PrintHeadToHeadSummary(NewHeadToHeadFromUserStats(account, opponentAccount, userStats)))
+		}
 
 	} else {
 		userData, err := getLastUserData(cmdBuilder, account)
@@ -326,6 +427,16 @@ func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 			log.Error(err)
 			return err
 		}
+		seasonLeaderboardID, err := getCurrentSeasonLeaderboardID(cmdBuilder)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		leaderboardIDs, err := ensureMatchMakerLeaderboards(cmdBuilder, matchMode, time.Duration(duration)*time.Hour)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
 		//ticketStringArgs := make(map[string]string)
 		//ticketDoubleArgs := make(map[string]float64)
 
@@ -336,36 +447,42 @@ func createTicket(cmdBuilder *commandsBuilder, cmd *cobra.Command, args []string
 		//doubleArgs[SEARCH_MAX_DURATION] = maxDuration
 		doubleArgs[SEARCH_MIN_DURATION] = float64(duration)
 		doubleArgs[SEARCH_MAX_DURATION] = float64(duration)
-		payload, _ := json.Marshal(&pb.CreateTicketRequest{
-			Ticket: &pb.Ticket{
-				SearchFields: &pb.SearchFields{
-					Tags: matchMode,
-					//StringArgs: ,
-					DoubleArgs: doubleArgs,
-				},
-				Extensions: map[string]*anypb.Any{
-					TICKET_EXTENSION_USER: &anypb.Any{Value: Marshal(
-						&TeamUser{
-							User: &User{
-								Discord: &DiscordUser{
-									AuthorID:      account.CustomId,
-									Username:      strings.Split(account.User.Username, "#")[0],
-									ChannelID:     userData.DiscordChannelID,
-									Discriminator: strings.Split(account.User.Username, "#")[1],
-									GuildID:       userData.DiscordGuildID,
-								},
-								Nakama: &NakamaUser{
-									CustomID:    account.CustomId,
-									DisplayName: account.User.DisplayName,
-									ID:          account.User.Id,
-									Username:    account.User.Username,
-									Wallet:      account.Wallet,
-								},
+		ticketRequest := &pb.Ticket{
+			SearchFields: &pb.SearchFields{
+				Tags: matchMode,
+				//StringArgs: ,
+				DoubleArgs: doubleArgs,
+			},
+			Extensions: map[string]*anypb.Any{
+				TICKET_EXTENSION_USER: &anypb.Any{Value: Marshal(
+					&TeamUser{
+						User: &User{
+							Discord: &DiscordUser{
+								AuthorID:      account.CustomId,
+								Username:      strings.Split(account.User.Username, "#")[0],
+								ChannelID:     userData.DiscordChannelID,
+								Discriminator: strings.Split(account.User.Username, "#")[1],
+								GuildID:       userData.DiscordGuildID,
+							},
+							Nakama: &NakamaUser{
+								CustomID:    account.CustomId,
+								DisplayName: account.User.DisplayName,
+								ID:          account.User.Id,
+								Username:    account.User.Username,
+								Wallet:      account.Wallet,
 							},
 						},
-					)},
-				},
+					},
+				)},
+				TICKET_EXTENSION_READY_DEADLINE:         &anypb.Any{Value: Marshal(time.Duration(readyDeadline) * time.Minute)},
+				TICKET_EXTENSION_ROUTED_LEADERBOARD_IDS: &anypb.Any{Value: Marshal(leaderboardIDs)},
 			},
+		}
+		if seasonLeaderboardID != "" {
+			ticketRequest.Extensions[TICKET_EXTENSION_SEASON_LEADERBOARD_ID] = &anypb.Any{Value: Marshal(seasonLeaderboardID)}
+		}
+		payload, _ := json.Marshal(&pb.CreateTicketRequest{
+			Ticket: ticketRequest,
 		})
 		log.Infof("%+v\n", string(payload))
 
@@ -420,9 +537,12 @@ func setupTicketFlags(cmd *cobra.Command, isCaptainsDraft bool) {
 	//cmd.Flags().Float64P(SEARCH_MIN_DURATION, "", 0, "min duration in hours")
 	//cmd.Flags().Float64P(SEARCH_MAX_DURATION, "", 48, "max duration in hours")
 	cmd.Flags().IntP("duration", "d", DEFAULT_MATCH_DURATION_HOURS, fmt.Sprintf("duration in hours, maximum duration is %v hours", MAX_MATCH_DURATION_HOURS))
+	cmd.Flags().IntP("ready-deadline", "", DEFAULT_READY_DEADLINE_MINUTES, "Minutes the players have to get ready, not ready players forfeit the match")
 	if isCaptainsDraft {
 		cmd.Flags().StringP("user", "u", "", "**Challenge** a specific user by the discord username#1234, @username or <@discord_user_id> (https://support.discord.com/hc/en-us/articles/206346498-Where-can-I-find-my-User-Server-Message-ID-)")
 		cmd.Flags().StringSliceP("mode", "m", []string{defaultMatchProfile}, fmt.Sprintf("Captains Draft mode. Available modes: %+v", CAPTAIN_DRAFT_MODES))
+		cmd.Flags().StringP("at", "", "", "Schedule the match at the date and time: "+SCHEDULE_TIME_LAYOUT)
+		cmd.Flags().StringP("tz", "", SCHEDULE_DEFAULT_TZ, "Time zone of the **--at** date and time, e.g. UTC or Europe/Berlin")
 	} else {
 		cmd.Flags().StringSliceP("mode", "m", []string{defaultMatchProfile}, fmt.Sprintf("Match Maker mode. Available modes: %+v", MATCH_MAKER_MODES))
 	}
@@ -23,6 +23,7 @@ import (
 
 	log "github.com/micro/go-micro/v2/logger"
 
+	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/gofrs/uuid"
@@ -31,6 +32,19 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	MAX_TOURNAMENT_CATEGORY          = 127
+	DEFAULT_TOURNAMENT_LIST_LIMIT    = 10
+	DEFAULT_TOURNAMENT_DURATION      = 24
+	DEFAULT_TOURNAMENT_MAX_SIZE      = 10000
+	DEFAULT_TOURNAMENT_MAX_NUM_SCORE = 3
+)
+
+var (
+	TOURNAMENT_SORT_ORDERS = []string{"desc", "asc"}
+	TOURNAMENT_OPERATORS   = []string{"best", "set", "incr", "decr"}
+)
+
 type TournamentCreateRequest struct {
 	ID            string
 	SortOrder     string // one of: "desc", "asc"
@@ -53,50 +67,242 @@ type TournamentDeleteRequest struct {
 	ID string
 }
 
+type TournamentLeaveRequest struct {
+	ID     string
+	UserID string
+}
+
+// TournamentGetRequest reads one tournament, the server returns an empty
+// payload when it does not exist
+type TournamentGetRequest struct {
+	ID string
+}
+
+// TournamentJoinedListRequest returns the IDs of the tournaments the user
+// has joined among TournamentIDs
+type TournamentJoinedListRequest struct {
+	UserID        string
+	TournamentIDs []string
+}
+
 var cmdTournamentAliases = []string{}
 
+func GetTournamentStatus(tournament *api.Tournament, now time.Time) string {
+	startActive := time.Unix(int64(tournament.StartActive), 0)
+	endActive := time.Unix(int64(tournament.EndActive), 0)
+	switch {
+	case now.Before(startActive):
+		return fmt.Sprintf("starts in **%v**", formatDuraiton(startActive.Sub(now)))
+	case tournament.EndActive == 0 || now.Before(endActive):
+		if tournament.EndActive == 0 {
+			return "**active**"
+		}
+		return fmt.Sprintf("**active**, ends in **%v**", formatDuraiton(endActive.Sub(now)))
+	case tournament.NextReset > 0:
+		return fmt.Sprintf("next round in **%v**", formatDuraiton(time.Unix(int64(tournament.NextReset), 0).Sub(now)))
+	default:
+		return "**finished**"
+	}
+}
+
+func PrintTournamentSize(tournament *api.Tournament) string {
+	if tournament.MaxSize == 0 {
+		return fmt.Sprintf("%v", tournament.Size)
+	}
+	return fmt.Sprintf("%v/%v", tournament.Size, tournament.MaxSize)
+}
+
+func PrintTournament(tournament *api.Tournament, joined bool, now time.Time) string {
+	msg := fmt.Sprintf("> **%v** `%v` category %v\n", tournament.Title, tournament.Id, tournament.Category)
+	if tournament.Description != "" {
+		msg += fmt.Sprintf("> %v\n", tournament.Description)
+	}
+	msg += fmt.Sprintf("> Status: %v\n", GetTournamentStatus(tournament, now))
+	msg += fmt.Sprintf("> Players: **%v**, scores per player: **%v**\n", PrintTournamentSize(tournament), tournament.MaxNumScore)
+	if joined {
+		msg += "> Joined: **yes**\n"
+	} else {
+		msg += "> Joined: **no**\n"
+	}
+	return msg
+}
+
+func PrintTournamentShort(tournament *api.Tournament, joined bool, now time.Time) string {
+	msg := fmt.Sprintf("> **%v** `%v` %v, players %v", tournament.Title, tournament.Id, GetTournamentStatus(tournament, now), PrintTournamentSize(tournament))
+	if joined {
+		msg += " **joined**"
+	}
+	return msg + "\n"
+}
+
+func getTournamentList(cmdBuilder *commandsBuilder, categoryStart uint32, categoryEnd uint32, limit int, cursor string) (*api.TournamentList, error) {
+	result, err := cmdBuilder.nakamaCtx.Client.ListTournaments(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentsRequest{
+		CategoryStart: &wrapperspb.UInt32Value{Value: categoryStart},
+		CategoryEnd:   &wrapperspb.UInt32Value{Value: categoryEnd},
+		Cursor:        cursor,
+		Limit:         &wrapperspb.Int32Value{Value: int32(limit)},
+	})
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// getTournament reads the tournament by ID on the server as the API has no
+// request by ID
+func getTournament(cmdBuilder *commandsBuilder, tournamentID string) (*api.Tournament, error) {
+	payload, _ := json.Marshal(&TournamentGetRequest{
+		ID: tournamentID,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentGet", Payload: string(payload)})
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var tournament *api.Tournament
+	if result.Payload != "" {
+		if err := json.Unmarshal([]byte(result.Payload), &tournament); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+	}
+	if tournament == nil {
+		return nil, fmt.Errorf("Tournament %v not found", tournamentID)
+	}
+	return tournament, nil
+}
+
+// getJoinedTournamentIDs returns which of the tournaments the user has joined
+// in one request
+func getJoinedTournamentIDs(cmdBuilder *commandsBuilder, userID string, tournamentIDs []string) ([]string, error) {
+	payload, _ := json.Marshal(&TournamentJoinedListRequest{
+		UserID:        userID,
+		TournamentIDs: tournamentIDs,
+	})
+	log.Infof("%+v\n", string(payload))
+
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentJoinedList", Payload: string(payload)})
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	var joinedTournamentIDs []string
+	if result.Payload != "" {
+		if err := json.Unmarshal([]byte(result.Payload), &joinedTournamentIDs); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+	}
+	return joinedTournamentIDs, nil
+}
+
+func isTournamentJoined(cmdBuilder *commandsBuilder, tournamentID string, userID string) (bool, error) {
+	result, err := cmdBuilder.nakamaCtx.Client.ListTournamentRecords(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentRecordsRequest{
+		TournamentId: tournamentID,
+		OwnerIds:     []string{userID},
+		Limit:        &wrapperspb.Int32Value{Value: 1},
+	})
+	if err != nil {
+		log.Error(err)
+		return false, err
+	}
+	return len(result.GetOwnerRecords()) > 0, nil
+}
+
+func validateTournamentJoin(tournament *api.Tournament, joined bool) error {
+	if joined {
+		return fmt.Errorf("You have already joined the tournament **%v**", tournament.Title)
+	}
+	if !tournament.CanEnter {
+		return fmt.Errorf("The tournament **%v** is not open for entry", tournament.Title)
+	}
+	if tournament.MaxSize > 0 && tournament.Size >= tournament.MaxSize {
+		return fmt.Errorf("The tournament **%v** is full, %v players maximum", tournament.Title, tournament.MaxSize)
+	}
+	return nil
+}
+
 func getCmdTournamentCreate(cmdBuilder *commandsBuilder) *cobra.Command {
 	cmdTournamentCreate := &cobra.Command{
-		Use:     "tournament ",
-		Aliases: cmdTournamentAliases,
-		Short:   "Short",
-		Long:    `Long`,
-		//Args:      matchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
-		//ValidArgs: validArgs,
+		Use:   "create [title]",
+		Short: "Create a **tournament**",
+		Long: `Create a **tournament**
+Rounds of **--duration** hours are started by the **--resetSchedule** in the cron format, e.g. "0 12 * * *" or 0,12,*,*,*`,
+		Args: matchAll(cobra.MinimumNArgs(1)),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
-			//initTournamentCreateFlags(cmd)
-			err := cmd.ParseFlags(args)
-			if err != nil {
-				log.Error(err)
-				return err
-			}
-
+			title := strings.Join(args, " ")
 			sortOrder, _ := cmd.Flags().GetString("sortOrder")
+			if !IsStringInSlice(sortOrder, TOURNAMENT_SORT_ORDERS) {
+				return fmt.Errorf("Sort order %v is invalid. Available sort orders: %+v", sortOrder, TOURNAMENT_SORT_ORDERS)
+			}
 			operator, _ := cmd.Flags().GetString("operator")
-			resetSchedule, err := cmd.Flags().GetString("resetSchedule")
-			title, _ := cmd.Flags().GetString("title")
-			desc, _ := cmd.Flags().GetString("desc")
+			if !IsStringInSlice(operator, TOURNAMENT_OPERATORS) {
+				return fmt.Errorf("Operator %v is invalid. Available operators: %+v", operator, TOURNAMENT_OPERATORS)
+			}
+			resetSchedule, _ := cmd.Flags().GetString("resetSchedule")
+			if resetSchedule != "" {
+				resetSchedule = NormalizeCronExpression(resetSchedule)
+				if err := ValidateCronExpression(resetSchedule); err != nil {
+					return err
+				}
+			}
 			category, _ := cmd.Flags().GetInt("category")
+			if category < 0 || category > MAX_TOURNAMENT_CATEGORY {
+				return fmt.Errorf("category must be between 0 and %v", MAX_TOURNAMENT_CATEGORY)
+			}
 			duration, _ := cmd.Flags().GetInt("duration")
+			if duration < 1 {
+				return fmt.Errorf("duration can not be less than 1 hour")
+			}
 			maxSize, _ := cmd.Flags().GetInt("maxSize")
+			if maxSize < 0 {
+				return fmt.Errorf("maxSize can not be negative, use 0 for no limit")
+			}
 			maxNumScore, _ := cmd.Flags().GetInt("maxNumScore")
+			if maxNumScore < 1 {
+				return fmt.Errorf("maxNumScore can not be less than 1")
+			}
+
+			dateTimeStart, err := parseDateFlag(cmd, "start")
+			if err != nil {
+				return err
+			}
+			if dateTimeStart.IsZero() {
+				dateTimeStart = time.Now().UTC()
+			}
+			dateTimeEnd, err := parseDateFlag(cmd, "end")
+			if err != nil {
+				return err
+			}
+			endTime := 0
+			if !dateTimeEnd.IsZero() {
+				if dateTimeEnd.Sub(dateTimeStart) < time.Duration(duration)*time.Hour {
+					return fmt.Errorf("The tournament must end at least %v hours after it starts", duration)
+				}
+				endTime = int(dateTimeEnd.Unix())
+			}
+
+			desc, _ := cmd.Flags().GetString("desc")
 			joinRequired, _ := cmd.Flags().GetBool("joinRequired")
 			debug, _ := cmd.Flags().GetBool("debug")
+			tournamentID := uuid.Must(uuid.NewV4()).String()
 
 			payload, _ := json.Marshal(&TournamentCreateRequest{
-				ID:        uuid.Must(uuid.NewV4()).String(),
-				SortOrder: sortOrder,
-				Operator:  operator,
-				//ResetSchedule: strings.ReplaceAll(strings.Join(resetSchedule, " "), "+", "*"),
-				ResetSchedule: strings.ReplaceAll(resetSchedule, ",", " "),
-				Metadata:      map[string]interface{}{},
+				ID:            tournamentID,
+				SortOrder:     sortOrder,
+				Operator:      operator,
+				ResetSchedule: resetSchedule,
+				Metadata:      DEFAULT_SCORE_CODEC.Metadata(),
 				Title:         title,
 				Description:   desc,
 				Category:      category,
-				StartTime:     int(time.Now().UTC().Unix()),
-				EndTime:       0,
-				Duration:      duration,
+				StartTime:     int(dateTimeStart.Unix()),
+				EndTime:       endTime,
+				Duration:      duration * int(time.Hour/time.Second),
 				MaxSize:       maxSize,
 				MaxNumScore:   maxNumScore,
 				JoinRequired:  joinRequired,
@@ -104,142 +310,290 @@ func getCmdTournamentCreate(cmdBuilder *commandsBuilder) *cobra.Command {
 			})
 			log.Infof("%+v\n", string(payload))
 
-			result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentCreate", Payload: string(payload)})
-			if err != nil {
+			if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentCreate", Payload: string(payload)}); err != nil {
 				log.Error(err)
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result.Payload))
+			fmt.Fprint(cmd.OutOrStdout(), fmt.Sprintf("> Tournament **%v** created: `%v`\n> Join with **dl tournament join %v**", title, tournamentID, tournamentID))
 			return nil
 		},
 	}
-	cmdTournamentCreate.Flags().StringP("sortOrder", "s", "desc", "usage")
-	cmdTournamentCreate.Flags().StringP("operator", "o", "best", "usage")
-	cmdTournamentCreate.Flags().StringP("resetSchedule", "r", "", "0,12,*,*,*")
-	cmdTournamentCreate.Flags().StringP("title", "t", "", "usage")
-	cmdTournamentCreate.MarkFlagRequired("title")
-	cmdTournamentCreate.Flags().StringP("desc", "", "", "usage")
-	cmdTournamentCreate.Flags().IntP("category", "c", 1, "usage")
-	cmdTournamentCreate.Flags().IntP("duration", "", 3600, "usage")
-	cmdTournamentCreate.Flags().IntP("maxSize", "", 10000, "usage")
-	cmdTournamentCreate.Flags().IntP("maxNumScore", "", 3, "usage")
-	cmdTournamentCreate.Flags().BoolP("joinRequired", "j", true, "usage")
-	cmdTournamentCreate.Flags().BoolP("debug", "", true, "usage")
+	cmdTournamentCreate.Flags().StringP("sortOrder", "s", "desc", fmt.Sprintf("Sort order of the records: %+v", TOURNAMENT_SORT_ORDERS))
+	cmdTournamentCreate.Flags().StringP("operator", "o", "best", fmt.Sprintf("Score operator: %+v", TOURNAMENT_OPERATORS))
+	cmdTournamentCreate.Flags().StringP("resetSchedule", "r", "", "Round schedule in the cron format, e.g. 0,12,*,*,*")
+	cmdTournamentCreate.Flags().StringP("desc", "", "", "Description of the tournament")
+	cmdTournamentCreate.Flags().IntP("category", "c", 1, fmt.Sprintf("Category of the tournament, 0-%v", MAX_TOURNAMENT_CATEGORY))
+	cmdTournamentCreate.Flags().StringP("start", "", "", "Tournament start date, now by default: "+DATE_LAYOUT)
+	cmdTournamentCreate.Flags().StringP("end", "", "", "Tournament end date, never by default: "+DATE_LAYOUT)
+	cmdTournamentCreate.Flags().IntP("duration", "d", DEFAULT_TOURNAMENT_DURATION, "Duration of a round in hours")
+	cmdTournamentCreate.Flags().IntP("maxSize", "", DEFAULT_TOURNAMENT_MAX_SIZE, "Maximum number of players, 0 for no limit")
+	cmdTournamentCreate.Flags().IntP("maxNumScore", "", DEFAULT_TOURNAMENT_MAX_NUM_SCORE, "Maximum number of scores per player in a round")
+	cmdTournamentCreate.Flags().BoolP("joinRequired", "j", true, "Players must join the tournament to submit scores")
+	cmdTournamentCreate.Flags().BoolP("debug", "", false, "Debug tournament")
 	return cmdTournamentCreate
 }
 
-func getCmdTournamentGet(cmdBuilder *commandsBuilder) *cobra.Command {
-	cmdTournamentGet := &cobra.Command{
-		Use:     "tournament",
-		Aliases: cmdTournamentAliases,
-		Short:   "Short",
-		Long:    `Long`,
+func getCmdTournamentList(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the **tournaments**",
+		Long:    `List the **tournaments** with their status, size and joined status`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
 			categoryStart, _ := cmd.Flags().GetUint32("categoryStart")
 			categoryEnd, _ := cmd.Flags().GetUint32("categoryEnd")
-			timeStartString, _ := cmd.Flags().GetString("timeStart")
-			timeEndString, _ := cmd.Flags().GetString("timeEnd")
-			timeStartParsed, _ := time.Parse(TIME_LAYOUT, timeStartString)
-			timeEndParsed, _ := time.Parse(TIME_LAYOUT, timeEndString)
+			if categoryStart > categoryEnd || categoryEnd > MAX_TOURNAMENT_CATEGORY {
+				return fmt.Errorf("categories must be between 0 and %v, start not after end", MAX_TOURNAMENT_CATEGORY)
+			}
+			limit, _ := cmd.Flags().GetInt("limit")
+			if limit < 1 || limit > MAX_LIST_LIMIT {
+				return fmt.Errorf("limit must be between 1 and %v", MAX_LIST_LIMIT)
+			}
 			cursor, _ := cmd.Flags().GetString("cursor")
-			limit, _ := cmd.Flags().GetInt32("limit")
-			result, err := cmdBuilder.nakamaCtx.Client.ListTournaments(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentsRequest{
-				CategoryStart: &wrapperspb.UInt32Value{Value: categoryStart},
-				CategoryEnd:   &wrapperspb.UInt32Value{Value: categoryEnd},
-				Cursor:        cursor,
-				StartTime:     &wrapperspb.UInt32Value{Value: uint32(timeStartParsed.Unix())},
-				EndTime:       &wrapperspb.UInt32Value{Value: uint32(timeEndParsed.Unix())},
-				Limit:         &wrapperspb.Int32Value{Value: limit},
-			})
-			/*result, err = cmdBuilder.nakamaCtx.Client.ListTournaments(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentsRequest{
-				CategoryStart: &wrapperspb.UInt32Value{Value: categoryStart},
-				CategoryEnd:   &wrapperspb.UInt32Value{Value: categoryEnd},
-				Cursor:        cursor,
-				Limit:         &wrapperspb.Int32Value{Value: limit},
-			})
-			*/
+
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			result, err := getTournamentList(cmdBuilder, categoryStart, categoryEnd, limit, cursor)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(result.Tournaments) == 0 {
+				fmt.Fprint(cmd.OutOrStdout(), "No tournaments found")
+				return nil
+			}
+
+			var tournamentIDs []string
+			for _, tournament := range result.Tournaments {
+				tournamentIDs = append(tournamentIDs, tournament.Id)
+			}
+			joinedTournamentIDs, err := getJoinedTournamentIDs(cmdBuilder, account.User.Id, tournamentIDs)
 			if err != nil {
 				log.Error(err)
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result))
+			now := time.Now().UTC()
+			msg := "> Tournaments:\n"
+			for _, tournament := range result.Tournaments {
+				msg += PrintTournamentShort(tournament, IsStringInSlice(tournament.Id, joinedTournamentIDs), now)
+			}
+			if result.Cursor != "" {
+				msg += fmt.Sprintf("> More tournaments: **dl tournament list --limit %v --cursor %v**\n", limit, result.Cursor)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	cmd.Flags().Uint32P("categoryStart", "", 0, "First category to list")
+	cmd.Flags().Uint32P("categoryEnd", "", MAX_TOURNAMENT_CATEGORY, "Last category to list")
+	cmd.Flags().StringP("cursor", "c", "", "Cursor of the page to show")
+	cmd.Flags().IntP("limit", "l", DEFAULT_TOURNAMENT_LIST_LIMIT, fmt.Sprintf("Number of tournaments per page, maximum is %v", MAX_LIST_LIMIT))
+	return cmd
+}
+
+func getCmdTournamentShow(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show the **tournament**",
+		Long:  `Show the **tournament** with its time remaining, size limits and joined status`,
+		Args:  matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			joined, err := isTournamentJoined(cmdBuilder, tournament.Id, account.User.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			msg := PrintTournament(tournament, joined, time.Now().UTC())
+			tournamentRegistration, _, err := getFinalizedTournamentRegistration(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if tournamentRegistration != nil {
+				msg += PrintTournamentRegistration(tournamentRegistration, account.User.Id)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), msg)
 			return nil
 		},
 	}
-	cmdTournamentGet.Flags().Uint32P("categoryStart", "", 0, "usage")
-	cmdTournamentGet.Flags().Uint32P("categoryEnd", "", 127, "usage")
-	cmdTournamentGet.Flags().StringP("cursor", "c", "", "usage")
-	cmdTournamentGet.Flags().StringP("timeStart", "s", TIME_START_DEFAULT, "usage: "+TIME_LAYOUT)
-	cmdTournamentGet.Flags().StringP("timeEnd", "e", TIME_END_DEFAULT, "usage: "+TIME_LAYOUT)
-	cmdTournamentGet.Flags().Int32P("limit", "l", MAX_LIST_LIMIT, "usage")
-	return cmdTournamentGet
+	return cmd
 }
 
 func getCmdTournamentDelete(cmdBuilder *commandsBuilder) *cobra.Command {
 	cmdTournamentDelete := &cobra.Command{
-		Use:     "tournament [id]",
-		Aliases: cmdTournamentAliases,
-		Short:   "Short",
-		Long:    `Long`,
-		Args:    matchAll(cobra.MinimumNArgs(1)),
+		Use:   "delete [id]",
+		Short: "Delete the **tournament**",
+		Long:  `Delete the **tournament** with all its records`,
+		Args:  matchAll(cobra.ExactArgs(1)),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
-			id, _ := cmd.Flags().GetString("id")
-			if id == "" && len(args) > 0 {
-				id = args[0]
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
 			}
 			payload, _ := json.Marshal(&TournamentDeleteRequest{
-				ID: id,
+				ID: tournament.Id,
 			})
 			log.Infof("%+v\n", string(payload))
 
-			result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentDelete", Payload: string(payload)})
-			if err != nil {
+			if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentDelete", Payload: string(payload)}); err != nil {
 				log.Error(err)
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result.Payload))
+			fmt.Fprint(cmd.OutOrStdout(), fmt.Sprintf("> Tournament **%v** deleted", tournament.Title))
 			return nil
 		},
 	}
-	cmdTournamentDelete.Flags().StringP("id", "i", "", "usage")
 	return cmdTournamentDelete
 }
 
 func getCmdTournamentJoin(cmdBuilder *commandsBuilder) *cobra.Command {
 	cmdTournamentJoin := &cobra.Command{
-		Use:     "tournament [id]",
-		Aliases: cmdTournamentAliases,
-		Short:   "Short",
-		Long:    `Long`,
-		Args:    matchAll(cobra.MinimumNArgs(1)),
+		Use:   "join [id]",
+		Short: "Join the **tournament**",
+		Long:  `Join the **tournament** to submit the scores, the tournaments with the registration are joined with **dl tournament register**`,
+		Args:  matchAll(cobra.ExactArgs(1)),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
-			id, _ := cmd.Flags().GetString("id")
-			if id == "" && len(args) > 0 {
-				id = args[0]
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
 			}
-			payload, _ := json.Marshal(&TournamentDeleteRequest{
-				ID: id,
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournamentRegistration, _, err := getTournamentRegistration(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if tournamentRegistration != nil {
+				msg, err := registerTournamentUser(cmdBuilder, tournament, account)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(cmd.OutOrStdout(), msg)
+				return nil
+			}
+			joined, err := isTournamentJoined(cmdBuilder, tournament.Id, account.User.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if err := validateTournamentJoin(tournament, joined); err != nil {
+				return err
+			}
+
+			if _, err := cmdBuilder.nakamaCtx.Client.JoinTournament(cmdBuilder.nakamaCtx.Ctx, &api.JoinTournamentRequest{
+				TournamentId: tournament.Id,
+			}); err != nil {
+				log.Error(err)
+				return err
+			}
+
+			tournament.Size += 1
+			fmt.Fprint(cmd.OutOrStdout(), fmt.Sprintf("<@%v> joined the tournament:\n", account.CustomId)+PrintTournament(tournament, true, time.Now().UTC()))
+			return nil
+		},
+	}
+	return cmdTournamentJoin
+}
+
+func getCmdTournamentLeave(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "leave [id]",
+		Short: "Leave the **tournament**",
+		Long: `Leave the **tournament**, your records of the tournament are removed
+The registered users withdraw and their place goes to the first user on the waitlist`,
+		Args: matchAll(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournament, err := getTournament(cmdBuilder, args[0])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			tournamentRegistration, version, err := getFinalizedTournamentRegistration(cmdBuilder, tournament.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if tournamentRegistration != nil {
+				msg, err := withdrawTournamentUser(cmdBuilder, tournament, tournamentRegistration, version, account)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(cmd.OutOrStdout(), msg)
+				return nil
+			}
+			joined, err := isTournamentJoined(cmdBuilder, tournament.Id, account.User.Id)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if !joined {
+				return fmt.Errorf("You have not joined the tournament **%v**", tournament.Title)
+			}
+
+			payload, _ := json.Marshal(&TournamentLeaveRequest{
+				ID:     tournament.Id,
+				UserID: account.User.Id,
 			})
 			log.Infof("%+v\n", string(payload))
 
-			result, err := cmdBuilder.nakamaCtx.Client.JoinTournament(cmdBuilder.nakamaCtx.Ctx, &api.JoinTournamentRequest{
-				TournamentId: id,
-			})
-			if err != nil {
+			if _, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "TournamentLeave", Payload: string(payload)}); err != nil {
 				log.Error(err)
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result))
+			fmt.Fprint(cmd.OutOrStdout(), fmt.Sprintf("<@%v> left the tournament **%v**", account.CustomId, tournament.Title))
 			return nil
 		},
 	}
-	cmdTournamentJoin.Flags().StringP("id", "i", "", "usage")
-	return cmdTournamentJoin
+	return cmd
+}
+
+func getCmdTournament(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tournament",
+		Aliases: []string{"tour"},
+		Short:   "League **tournaments**",
+		Long:    `League **tournaments**`,
+	}
+	cmd.AddCommand(getCmdTournamentList(cmdBuilder))
+	cmd.AddCommand(getCmdTournamentShow(cmdBuilder))
+	cmd.AddCommand(getCmdTournamentJoin(cmdBuilder))
+	cmd.AddCommand(getCmdTournamentLeave(cmdBuilder))
+	cmd.AddCommand(getCmdTournamentRegister(cmdBuilder))
+	cmd.AddCommand(getCmdTournamentCheckIn(cmdBuilder))
+	cmd.AddCommand(getCmdTournamentWithdraw(cmdBuilder))
+	cmd.AddCommand(getCmdTournamentRecords(cmdBuilder))
+	return cmd
 }
@@ -21,7 +21,6 @@ import (
 	log "github.com/micro/go-micro/v2/logger"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
-	nakamaContext "github.com/challenge-league/nakama-go/context"
 	"github.com/heroiclabs/nakama-common/api"
 
 	"github.com/spf13/cobra"
@@ -75,85 +74,73 @@ func getCmdTournamentRecordCreate(cmdBuilder *commandsBuilder) *cobra.Command {
 	return cmdTournamentRecordCreate
 }
 
-func getCmdTournamentRecordGet(cmdBuilder *commandsBuilder) *cobra.Command {
-	cmdTournamentRecordGet := &cobra.Command{
-		Use:     "tournamentRecord",
-		Aliases: cmdTournamentRecordAliases,
-		Short:   "Short",
-		Long:    `Long`,
+func getCmdTournamentRecords(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "records [id]",
+		Short: "Get the **tournament** records",
+		Long: `Get the **tournament** records
+Browse the pages with **--cursor**, see the ranks around a user with **--around me** or **--around @user**`,
+		Args: matchAll(cobra.ExactArgs(1)),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Infof("%+v\n", args)
+			limit, _ := cmd.Flags().GetInt("limit")
+			if limit < 1 || limit > MAX_LIST_LIMIT {
+				return fmt.Errorf("limit must be between 1 and %v", MAX_LIST_LIMIT)
+			}
 			cursor, _ := cmd.Flags().GetString("cursor")
+			around, _ := cmd.Flags().GetString("around")
 			expiry, _ := cmd.Flags().GetInt64("expiry")
-			tournamentID, _ := cmd.Flags().GetString("tournamentID")
-			limit, _ := cmd.Flags().GetInt32("limit")
-			ownerIDs, _ := cmd.Flags().GetStringSlice("ownerIDs")
-			if len(ownerIDs) == 0 {
-				userData, _ := nakamaContext.UserDataFromSession(cmdBuilder.nakamaCtx.Session)
-				ownerIDs = []string{userData["uid"].(string)}
-			}
 
-			result, err := cmdBuilder.nakamaCtx.Client.ListTournamentRecords(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentRecordsRequest{
-				Cursor:       cursor,
-				Expiry:       &wrapperspb.Int64Value{Value: expiry},
-				TournamentId: tournamentID,
-				Limit:        &wrapperspb.Int32Value{Value: limit},
-				OwnerIds:     ownerIDs,
-			})
+			tournament, err := getTournament(cmdBuilder, args[0])
 			if err != nil {
 				log.Error(err)
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result))
-			return nil
-		},
-	}
-
-	cmdTournamentRecordGet.Flags().StringP("cursor", "c", "", "usage")
-	cmdTournamentRecordGet.Flags().Int64P("expiry", "e", 0, "usage")
-	cmdTournamentRecordGet.Flags().StringP("tournamentID", "i", "", "usage")
-	cmdTournamentRecordGet.Flags().Int32P("limit", "l", 100, "usage")
-	cmdTournamentRecordGet.Flags().StringSliceP("ownerIDs", "o", []string{}, "usage")
-	return cmdTournamentRecordGet
-}
-
-func getCmdTournamentRecordAroundOwnerGet(cmdBuilder *commandsBuilder) *cobra.Command {
-	cmdTournamentRecordGet := &cobra.Command{
-		Use: "tournamentRecordAroundOwner",
-		//Aliases: cmdTournamentAliases,
-		Short: "Short",
-		Long:  `Long`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			log.Infof("%+v\n", args)
-			ownerID, _ := cmd.Flags().GetString("ownerID")
-			if ownerID == "" {
-				userData, _ := nakamaContext.UserDataFromSession(cmdBuilder.nakamaCtx.Session)
-				ownerID = userData["uid"].(string)
+			var result *api.TournamentRecordList
+			if around != "" {
+				var account *api.Account
+				if account, err = getLeaderboardAroundAccount(cmdBuilder, around); err != nil {
+					log.Error(err)
+					return err
+				}
+				result, err = cmdBuilder.nakamaCtx.Client.ListTournamentRecordsAroundOwner(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentRecordsAroundOwnerRequest{
+					TournamentId: tournament.Id,
+					Limit:        &wrapperspb.UInt32Value{Value: uint32(limit)},
+					OwnerId:      account.User.Id,
+					Expiry:       &wrapperspb.Int64Value{Value: expiry},
+				})
+			} else {
+				result, err = cmdBuilder.nakamaCtx.Client.ListTournamentRecords(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentRecordsRequest{
+					TournamentId: tournament.Id,
+					Limit:        &wrapperspb.Int32Value{Value: int32(limit)},
+					Cursor:       cursor,
+					Expiry:       &wrapperspb.Int64Value{Value: expiry},
+				})
 			}
-			expiry, _ := cmd.Flags().GetInt64("expiry")
-			tournamentID, _ := cmd.Flags().GetString("tournamentID")
-			limit, _ := cmd.Flags().GetUint32("limit")
-
-			result, err := cmdBuilder.nakamaCtx.Client.ListTournamentRecordsAroundOwner(cmdBuilder.nakamaCtx.Ctx, &api.ListTournamentRecordsAroundOwnerRequest{
-				OwnerId:      ownerID,
-				Expiry:       &wrapperspb.Int64Value{Value: expiry},
-				TournamentId: tournamentID,
-				Limit:        &wrapperspb.UInt32Value{Value: limit},
-			})
 			if err != nil {
 				log.Error(err)
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), MarshalIndent(result))
+			if len(result.GetRecords()) > 0 {
+				codec, err := getTournamentScoreCodec(tournament)
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+				fmt.Fprint(cmd.OutOrStdout(), fmt.Sprintf("> Tournament **%v**:\n", tournament.Title)+
+					PrintLeaderboardRecords(result.GetRecords(), codec)+
+					PrintLeaderboardCursors(result, fmt.Sprintf("dl tournament records %v --limit %v", tournament.Id, limit)))
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), fmt.Sprintf("No records found for the tournament **%v**", tournament.Title))
+			}
 			return nil
 		},
 	}
-
-	cmdTournamentRecordGet.Flags().StringP("ownerID", "o", "", "usage")
-	cmdTournamentRecordGet.Flags().Int64P("expiry", "e", 0, "usage")
-	cmdTournamentRecordGet.Flags().StringP("tournamentID", "i", "", "usage")
-	cmdTournamentRecordGet.Flags().Uint32P("limit", "l", 100, "usage")
-	return cmdTournamentRecordGet
+	cmd.Flags().StringP("around", "a", "", "Show the ranks around the user, **me** for yourself")
+	cmd.Flags().StringP("cursor", "c", "", "Cursor of the page to show")
+	cmd.Flags().IntP("limit", "l", DEFAULT_LEADERBOARD_PAGE_LIMIT, fmt.Sprintf("Number of records per page, maximum is %v", MAX_LIST_LIMIT))
+	cmd.Flags().Int64P("expiry", "e", 0, "Show the records of the round that expired at this unix time")
+	return cmd
 }
@@ -0,0 +1,175 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TeamTrade is a swap of two drafted players proposed by one captain
+// and waiting for the approval of the other captain.
+type TeamTrade struct {
+	CaptainUserID    string
+	CaptainDiscordID string
+	UserIDs          []string
+	DiscordIDs       []string
+	DateTime         time.Time
+}
+
+type TeamTradeRequest struct {
+	MatchID       string
+	CaptainUserID string
+	UserIDs       []string
+}
+
+func PrintTeamTrade(teamTrade *TeamTrade) string {
+	if teamTrade == nil {
+		return ""
+	}
+	return ExecuteTemplate(
+		`> Pending trade offered by <@{{.CaptainDiscordID}}>:{{range $index, $element := .DiscordIDs}}{{if $index}} <->{{end}} <@{{.}}>{{end}}
+> To accept the trade please type: **dl trade --accept**
+`,
+		teamTrade)
+}
+
+func getCaptainsDraftMatchState(cmdBuilder *commandsBuilder, account *api.Account) (*MatchState, error) {
+	matchState, err := getLastUserMatchState(cmdBuilder, account, MATCH_COLLECTION)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if matchState == nil {
+		return nil, fmt.Errorf("No match found for <@%v>", account.CustomId)
+	}
+	if !isCaptainsDraft(matchState.MatchType) {
+		return nil, fmt.Errorf("The match **%v** is not a Captains Draft match", matchState.MatchID)
+	}
+	if matchState.Started {
+		return nil, fmt.Errorf("The match **%v** has already started", matchState.MatchID)
+	}
+	if !IsStringInSlice(account.User.Id, matchState.CaptainUserIDs) {
+		return nil, fmt.Errorf("Only captains can trade players")
+	}
+	return matchState, nil
+}
+
+func validateTeamTrade(cmdBuilder *commandsBuilder, account *api.Account, matchState *MatchState, args []string) ([]string, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("Please specify your player and the opponent player to trade")
+	}
+	var userIDs []string
+	for i, identifier := range args {
+		userAccount, err := getAccount(cmdBuilder, identifier)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		teamUser, teamNumber := GetUserAndTeamNumberByUserID(userAccount.User.Id, matchState)
+		if teamUser == nil {
+			return nil, fmt.Errorf("<@%v> has not been drafted in the match **%v**", userAccount.CustomId, matchState.MatchID)
+		}
+		if teamUser.Captain {
+			return nil, fmt.Errorf("Captain <@%v> can not be traded", userAccount.CustomId)
+		}
+		captainTeamNumber := GetTeamNumberFromUserAndMatch(account.User.Id, matchState)
+		if i == 0 && teamNumber != captainTeamNumber {
+			return nil, fmt.Errorf("<@%v> is not in your team", userAccount.CustomId)
+		}
+		if i == 1 && teamNumber == captainTeamNumber {
+			return nil, fmt.Errorf("<@%v> is not in the opponent team", userAccount.CustomId)
+		}
+		userIDs = append(userIDs, userAccount.User.Id)
+	}
+	return userIDs, nil
+}
+
+func getCmdTeamTrade(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trade [your_user] [opponent_user]",
+		Short: "Offer the opponent captain to **trade** two drafted players before the match starts",
+		Long: `Offer the opponent captain to **trade** two drafted players before the match starts
+The opponent captain accepts the offer with **dl trade --accept**, any captain can cancel it with **dl trade --cancel**`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			matchState, err := getCaptainsDraftMatchState(cmdBuilder, account)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			accept, _ := cmd.Flags().GetBool("accept")
+			cancel, _ := cmd.Flags().GetBool("cancel")
+
+			request := &TeamTradeRequest{
+				MatchID:       matchState.MatchID,
+				CaptainUserID: account.User.Id,
+			}
+			rpcID := "TeamTradeOffer"
+			switch {
+			case accept || cancel:
+				if matchState.PendingTrade == nil {
+					return fmt.Errorf("There is no pending trade in the match **%v**", matchState.MatchID)
+				}
+				if accept && matchState.PendingTrade.CaptainUserID == account.User.Id {
+					return fmt.Errorf("The trade must be accepted by the opponent captain")
+				}
+				request.UserIDs = matchState.PendingTrade.UserIDs
+				rpcID = "TeamTradeCancel"
+				if accept {
+					rpcID = "TeamTradeAccept"
+				}
+			default:
+				if matchState.PendingTrade != nil {
+					return fmt.Errorf("There is already a pending trade in the match **%v**, please accept or cancel it first", matchState.MatchID)
+				}
+				if request.UserIDs, err = validateTeamTrade(cmdBuilder, account, matchState, args); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			payload, _ := json.Marshal(request)
+			log.Infof("%+v\n", string(payload))
+
+			result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: rpcID, Payload: string(payload)})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if result.Payload != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), result.Payload)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolP("accept", "a", false, "Accept the pending trade offered by the opponent captain")
+	cmd.Flags().BoolP("cancel", "c", false, "Cancel or decline the pending trade")
+	return cmd
+}
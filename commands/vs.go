@@ -0,0 +1,166 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	DEFAULT_HEAD_TO_HEAD_ENCOUNTERS = 5
+)
+
+type HeadToHeadEncounter struct {
+	MatchSummary *MatchSummary
+	ProofLinks   []string
+}
+
+// HeadToHead is the record of the first user against the second one
+type HeadToHead struct {
+	DiscordID         string
+	OpponentDiscordID string
+	Matches           int
+	Wins              int
+	Losses            int
+	Draws             int
+	Teammates         int
+	Encounters        []*HeadToHeadEncounter
+}
+
+// NewHeadToHeadEncounters lists the matches of the first user against the
+// opponent with the proofs reported for them
+func NewHeadToHeadEncounters(account *api.Account, matchStateList []*MatchState) []*HeadToHeadEncounter {
+	var encounters []*HeadToHeadEncounter
+	for _, matchState := range matchStateList {
+		encounter := &HeadToHeadEncounter{MatchSummary: NewMatchSummary(account.User.Id, matchState)}
+		for _, matchResult := range GetLastMatchResults(matchState) {
+			if matchResult.ProofLink != "" {
+				encounter.ProofLinks = append(encounter.ProofLinks, matchResult.ProofLink)
+			}
+		}
+		encounters = append(encounters, encounter)
+	}
+	return encounters
+}
+
+// NewHeadToHeadFromUserStats reads the record from the cached per-opponent
+// stats of the first user without the encounters
+func NewHeadToHeadFromUserStats(account *api.Account, opponentAccount *api.Account, userStats *UserStats) *HeadToHead {
+	headToHead := &HeadToHead{
+		DiscordID:         account.CustomId,
+		OpponentDiscordID: opponentAccount.CustomId,
+		Teammates:         userStats.Teammates[opponentAccount.CustomId],
+	}
+	if opponentStats, ok := userStats.Opponents[opponentAccount.CustomId]; ok {
+		headToHead.Matches = opponentStats.Matches
+		headToHead.Wins = opponentStats.Wins
+		headToHead.Losses = opponentStats.Losses
+		headToHead.Draws = opponentStats.Matches - opponentStats.Wins - opponentStats.Losses
+	}
+	return headToHead
+}
+
+func PrintHeadToHeadSummary(headToHead *HeadToHead) string {
+	return ExecuteTemplate(
+		`> Head-to-head <@{{.DiscordID}}> vs <@{{.OpponentDiscordID}}>: **{{.Wins}}** - **{{.Losses}}**{{if .Draws}} ({{.Draws}} draws){{end}} in {{.Matches}} matches, {{.Teammates}} matches as teammates
+`,
+		headToHead)
+}
+
+func PrintHeadToHead(headToHead *HeadToHead) string {
+	msg := PrintHeadToHeadSummary(headToHead)
+	for _, encounter := range headToHead.Encounters {
+		msg += PrintMatchSummary(encounter.MatchSummary)
+		for _, proofLink := range encounter.ProofLinks {
+			msg += fmt.Sprintf(">   %v\n", proofLink)
+		}
+	}
+	return msg
+}
+
+// getHeadToHead reads the record from the cached stats of the user and only
+// the last encounters from the match history of the user
+func getHeadToHead(cmdBuilder *commandsBuilder, account *api.Account, opponentAccount *api.Account, encountersCount int) (*HeadToHead, error) {
+	userStats, err := getUserStats(cmdBuilder, account)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	headToHead := NewHeadToHeadFromUserStats(account, opponentAccount, userStats)
+	if encountersCount <= 0 || headToHead.Matches == 0 {
+		return headToHead, nil
+	}
+
+	matchStateList, _, err := getArchivedMatchStateList(cmdBuilder, &MatchHistoryFilter{
+		UserID:          account.User.Id,
+		OpponentUserID:  opponentAccount.User.Id,
+		MaxResultsCount: encountersCount,
+	}, "")
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	headToHead.Encounters = NewHeadToHeadEncounters(account, matchStateList)
+	return headToHead, nil
+}
+
+func getCmdHeadToHead(cmdBuilder *commandsBuilder) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vs [user] [user]",
+		Short: "Get the **head-to-head** record of two users",
+		Long: `Get the **head-to-head** record of two users
+If only one user is specified the record is calculated against you`,
+		Args: matchAll(cobra.RangeArgs(1, 2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Infof("%+v\n", args)
+			account, err := cmdBuilder.nakamaCtx.Client.GetAccount(cmdBuilder.nakamaCtx.Ctx, &emptypb.Empty{})
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if len(args) == 2 {
+				if account, err = getAccount(cmdBuilder, args[0]); err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+			opponentAccount, err := getAccount(cmdBuilder, args[len(args)-1])
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			if opponentAccount.User.Id == account.User.Id {
+				return fmt.Errorf("Please select two different users")
+			}
+
+			last, _ := cmd.Flags().GetInt("last")
+			headToHead, err := getHeadToHead(cmdBuilder, account, opponentAccount, last)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), PrintHeadToHead(headToHead))
+			return nil
+		},
+	}
+	cmd.Flags().IntP("last", "l", DEFAULT_HEAD_TO_HEAD_ENCOUNTERS, "Number of the last encounters to show")
+	return cmd
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2020 Dmitry Kozlov dmitry.f.kozlov@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	log "github.com/micro/go-micro/v2/logger"
+)
+
+const (
+	DEFAULT_SUBMIT_GRACE_BEFORE = 5 * time.Minute
+	DEFAULT_SUBMIT_GRACE_AFTER  = 15 * time.Minute
+	DEFAULT_RESULT_GRACE_AFTER  = 24 * time.Hour
+)
+
+// SubmissionWindow is the time range in which the submits or the results
+// of a match are accepted, the grace periods cover the clock difference at
+// the start and the late proofs after the end
+type SubmissionWindow struct {
+	GraceBefore time.Duration
+	GraceAfter  time.Duration
+}
+
+type ServerTime struct {
+	DateTime time.Time
+}
+
+func GetSubmitWindow(matchState *MatchState) *SubmissionWindow {
+	if matchState.SubmitWindow != nil {
+		return matchState.SubmitWindow
+	}
+	return &SubmissionWindow{GraceBefore: DEFAULT_SUBMIT_GRACE_BEFORE, GraceAfter: DEFAULT_SUBMIT_GRACE_AFTER}
+}
+
+func GetResultWindow(matchState *MatchState) *SubmissionWindow {
+	if matchState.ResultWindow != nil {
+		return matchState.ResultWindow
+	}
+	return &SubmissionWindow{GraceBefore: DEFAULT_SUBMIT_GRACE_BEFORE, GraceAfter: DEFAULT_RESULT_GRACE_AFTER}
+}
+
+// ValidateSubmissionWindow explains why an entry made at the time is refused
+func ValidateSubmissionWindow(matchState *MatchState, window *SubmissionWindow, now time.Time) error {
+	if matchState.Status == MATCH_STATUS_CANCELED {
+		return fmt.Errorf("The match **%v** has been canceled", matchState.MatchID)
+	}
+	if matchState.DateTimeStart.IsZero() {
+		return fmt.Errorf("The match **%v** has not started yet, status: **%v**", matchState.MatchID, matchState.Status)
+	}
+	if opening := matchState.DateTimeStart.Add(-window.GraceBefore); now.Before(opening) {
+		return fmt.Errorf("The match **%v** starts at **%v**, entries are accepted from **%v**",
+			matchState.MatchID, formatTimeAsDate(matchState.DateTimeStart), formatTimeAsDate(opening))
+	}
+	// within the grace period before the start the match may not be started yet
+	if !matchState.Started && !now.Before(matchState.DateTimeStart) {
+		return fmt.Errorf("The match **%v** has not started yet, status: **%v**", matchState.MatchID, matchState.Status)
+	}
+	if closing := GetMatchDateTimeEnd(matchState).Add(window.GraceAfter); now.After(closing) {
+		return fmt.Errorf("The match **%v** ended at **%v**, entries were accepted until **%v** (%v grace period), it is **%v** now",
+			matchState.MatchID, formatTimeAsDate(GetMatchDateTimeEnd(matchState)), formatTimeAsDate(closing),
+			formatDuraiton(window.GraceAfter), formatTimeAsDate(now))
+	}
+	return nil
+}
+
+// getLastUserSubmissionMatchState returns the last match of the user, the
+// match is looked up in the archive as well since its entries are accepted
+// within the grace period after the end
+func getLastUserSubmissionMatchState(cmdBuilder *commandsBuilder, account *api.Account) (*MatchState, error) {
+	userData, err := getLastUserData(cmdBuilder, account)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if userData == nil || userData.MatchID == "" {
+		return nil, nil
+	}
+	return getMatchStateFromAnyCollection(cmdBuilder, userData.MatchID)
+}
+
+func ValidateSubmitAttempts(matchState *MatchState, submits *Submits) error {
+	if GetSubmitAttemptsLeft(matchState, submits) == 0 {
+		return fmt.Errorf("All **%v** submit attempts in the match **%v** have been used", matchState.MaxNumScore, matchState.MatchID)
+	}
+	return nil
+}
+
+// getServerTime timestamps the entries with the server clock, the clock of
+// the client can not be trusted
+func getServerTime(cmdBuilder *commandsBuilder) (time.Time, error) {
+	result, err := cmdBuilder.nakamaCtx.Client.RpcFunc(cmdBuilder.nakamaCtx.Ctx, &api.Rpc{Id: "ServerTimeGet"})
+	if err != nil {
+		log.Error(err)
+		return time.Time{}, err
+	}
+	var serverTime *ServerTime
+	if err := json.Unmarshal([]byte(result.Payload), &serverTime); err != nil {
+		log.Error(err)
+		return time.Time{}, err
+	}
+	return serverTime.DateTime.UTC(), nil
+}